@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/rs/zerolog/log"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/dataloader"
+)
+
+// cleaningOptions clear the table before loading.
+type cleaningOptions struct {
+	Truncate  bool   `kong:"name='truncate',xor='clean',help='Remove all the rows of the table before loading'"`
+	DelWhere  string `kong:"name='delete-where',xor='clean',help='Delete the rows matching this SQL condition before loading'"`
+	Yes       bool   `kong:"name='yes',help='Do not ask for confirmation before removing rows'"`
+	Protected string `kong:"name='protected-dsn',default='(?i)prod',env='RDL_PROTECTED_DSN',help='Refuse to remove rows from databases whose DSN matches this regular expression, empty to allow any'"`
+}
+
+// cleaning reports whether rows are removed before loading.
+func (o *cleaningOptions) cleaning() bool {
+	return o.Truncate || o.DelWhere != ""
+}
+
+// checkProtected returns an error if the DSN matches the protected pattern.
+func (o *cleaningOptions) checkProtected(conn *connectionOptions) error {
+	if !o.cleaning() || o.Protected == "" {
+		return nil
+	}
+
+	pattern, err := regexp.Compile(o.Protected)
+	if err != nil {
+		return fmt.Errorf("invalid --protected-dsn: %w", err)
+	}
+	dataSource, err := conn.dataSource()
+	if err != nil {
+		return err
+	}
+	if pattern.MatchString(dataSource) {
+		return fmt.Errorf("refusing to remove rows: the DSN matches the protected pattern %q", o.Protected)
+	}
+
+	return nil
+}
+
+// clean removes the rows of the table, after asking for confirmation unless --yes is set.
+func (o *cleaningOptions) clean(ctx context.Context, loader *dataloader.TableDataLoader, database string) error {
+	if !o.cleaning() {
+		return nil
+	}
+
+	referencing, err := loader.ReferencingTables(ctx)
+	if err != nil {
+		return err
+	}
+
+	var question string
+	if o.Truncate {
+		question = fmt.Sprintf("Remove all the rows of %s in %s?", loader.TableStruct.Name, database)
+	} else {
+		count, err := loader.CountWhere(ctx, o.DelWhere)
+		if err != nil {
+			return err
+		}
+		question = fmt.Sprintf("Delete %d rows of %s in %s matching %s?", count, loader.TableStruct.Name, database,
+			o.DelWhere)
+	}
+	if len(referencing) > 0 {
+		tables := strings.Join(referencing, ", ")
+		switch {
+		case !o.Truncate:
+			question += " The ON DELETE actions of the foreign keys of the referencing tables apply: " + tables + "."
+		case loader.DBType == "postgres":
+			question += " The rows of the referencing tables are removed too: " + tables + "."
+		default:
+			question += " The rows of the referencing tables are kept: " + tables + "."
+		}
+	}
+	if err = o.confirm(question); err != nil {
+		return err
+	}
+
+	if o.Truncate {
+		if err = loader.Truncate(ctx); err != nil {
+			return err
+		}
+		log.Info().Msgf("Truncated %s", loader.TableStruct.Name)
+		return nil
+	}
+
+	deleted, err := loader.DeleteWhere(ctx, o.DelWhere)
+	if err != nil {
+		return err
+	}
+	log.Info().Int64("rows", deleted).Msgf("Deleted the rows of %s matching %s", loader.TableStruct.Name, o.DelWhere)
+
+	return nil
+}
+
+// confirm asks the question on the terminal and returns an error unless it is answered yes.
+func (o *cleaningOptions) confirm(question string) error {
+	if o.Yes {
+		return nil
+	}
+	if !isatty.IsTerminal(os.Stdin.Fd()) {
+		return errors.New("cannot ask for confirmation without a terminal, use --yes")
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("cannot read the confirmation: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	default:
+		return errors.New("canceled, no rows were removed")
+	}
+}
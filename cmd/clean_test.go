@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckProtected(t *testing.T) {
+	prod := &connectionOptions{DBType: "mysql", DSN: "root:secret@tcp(db.PROD.example.com:3306)/shop"}
+	staging := &connectionOptions{DBType: "mysql", DSN: "root:secret@tcp(db.staging.example.com:3306)/shop"}
+
+	options := cleaningOptions{Truncate: true, Protected: "(?i)prod"}
+	require.ErrorContains(t, options.checkProtected(prod), "the DSN matches the protected pattern")
+	require.NoError(t, options.checkProtected(staging))
+
+	options = cleaningOptions{DelWhere: "id < 10", Protected: "(?i)prod"}
+	require.Error(t, options.checkProtected(prod))
+
+	// Nothing is removed, or the protection is disabled
+	options = cleaningOptions{Protected: "(?i)prod"}
+	require.NoError(t, options.checkProtected(prod))
+	options = cleaningOptions{Truncate: true}
+	require.NoError(t, options.checkProtected(prod))
+
+	options = cleaningOptions{Truncate: true, Protected: "("}
+	assert.ErrorContains(t, options.checkProtected(staging), "invalid --protected-dsn")
+}
+
+func TestConfirm(t *testing.T) {
+	options := cleaningOptions{Truncate: true, Yes: true}
+	assert.NoError(t, options.confirm("Remove all the rows?"))
+}
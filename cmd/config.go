@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/alecthomas/kong"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigFile is read, if it exists, before the file given in --config.
+const defaultConfigFile = "~/.random_data_loader.yaml"
+
+// configLoader reads a JSON or YAML configuration file whose keys are flag names, with dashes or underscores,
+// like dsn, log_level or rows. Flags given on the command line or in their environment variables take
+// precedence.
+func configLoader(r io.Reader) (kong.Resolver, error) {
+	// YAML is a superset of JSON, so both are read as YAML and handed to the kong JSON resolver
+	var values map[string]any
+	if err := yaml.NewDecoder(r).Decode(&values); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("cannot decode configuration: %w", err)
+	}
+
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode configuration: %w", err)
+	}
+
+	resolver, err := kong.JSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	return kong.ResolverFunc(func(ctx *kong.Context, parent *kong.Path, flag *kong.Flag) (any, error) {
+		for _, env := range flag.Envs {
+			if _, ok := os.LookupEnv(env); ok {
+				return nil, nil //nolint:nilnil // Not resolved, the environment variable is used.
+			}
+		}
+		return resolver.Resolve(ctx, parent, flag)
+	}), nil
+}
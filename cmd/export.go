@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/dataloader"
+	"github.com/cfsalguero/random_data_loader/internal/core/services/arrowsink"
+	"github.com/cfsalguero/random_data_loader/internal/core/services/filesink"
+	"github.com/cfsalguero/random_data_loader/internal/core/services/sqldump"
+)
+
+// exportCmd writes random rows for the table to a file.
+type exportCmd struct {
+	generationOptions `kong:"embed"`
+	reportingOptions  `kong:"embed"`
+
+	NumRows   int    `kong:"name='rows',default='10',help='Number of rows to generate'"`
+	BatchSize int    `kong:"name='batch',default='1000',help='Number of rows generated at a time, and row group size of Parquet files'"`
+	Output    string `kong:"name='output',xor='output',required,help='Write the generated rows to this file'"`
+	Format    string `kong:"name='format',default='csv',enum='csv,tsv,jsonl,parquet,arrow',help='Output file format (csv, tsv, jsonl, parquet, arrow)'"`
+	Compress  string `kong:"name='compress',default='none',enum='none,gzip,zstd',help='Output file compression (none, gzip, zstd)'"`
+	CSVNull   string `kong:"name='csv-null',help='Text written for NULL values in CSV files, e.g. \\N (empty by default)'"`
+	OutputSQL string `kong:"name='output-sql',xor='output',required,help='Write the generated rows as INSERT statements to this file'"`
+	SQLTx     bool   `kong:"name='sql-transactions',help='Wrap every INSERT statement of the SQL dump in its own transaction'"`
+}
+
+// Run writes the rows to the output file and logs the summary.
+func (c *exportCmd) Run(root *cli) error {
+	db, tableStruct, err := root.connect(false, 1)
+	if err != nil {
+		return err
+	}
+	defer closeDB(db)
+
+	loader, err := c.newLoader(db, root.DBType, tableStruct, c.BatchSize, 1)
+	if err != nil {
+		return err
+	}
+
+	stopMetrics := c.startReporting(loader)
+	defer stopMetrics()
+
+	err = c.exportData(signalContext(), loader, root.DBType)
+	if err == nil || errors.Is(err, context.Canceled) {
+		logSummary(loader.Stats())
+	}
+	if errors.Is(err, context.Canceled) {
+		log.Warn().Msg("Export interrupted")
+	}
+
+	return err
+}
+
+func (c *exportCmd) exportData(ctx context.Context, loader *dataloader.TableDataLoader, dbType string) error {
+	sink, err := c.newSink(dbType)
+	if err != nil {
+		return err
+	}
+
+	if err = loader.ExportData(ctx, sink, c.NumRows); err != nil {
+		_ = sink.Close()
+		return err
+	}
+
+	return sink.Close()
+}
+
+func (c *exportCmd) newSink(dbType string) (dataloader.Sink, error) {
+	if c.OutputSQL == "" {
+		if c.Format == arrowsink.FormatParquet || c.Format == arrowsink.FormatArrow {
+			return arrowsink.New(c.Output, c.Format, c.Compress, c.BatchSize)
+		}
+		return filesink.New(c.Output, c.Format, c.Compress, c.CSVNull)
+	}
+
+	out, err := filesink.Create(c.OutputSQL, c.Compress)
+	if err != nil {
+		return nil, err
+	}
+
+	sink, err := sqldump.New(out, dbType, c.SQLTx)
+	if err != nil {
+		_ = out.Close()
+		return nil, err
+	}
+
+	return sink, nil
+}
@@ -0,0 +1,582 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-isatty"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/dataloader"
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+	"github.com/cfsalguero/random_data_loader/internal/core/metrics"
+	"github.com/cfsalguero/random_data_loader/internal/core/services/clone"
+	"github.com/cfsalguero/random_data_loader/internal/core/services/dbconn"
+	"github.com/cfsalguero/random_data_loader/internal/core/services/ddl"
+	"github.com/cfsalguero/random_data_loader/internal/core/services/indexes"
+	mysqlparser "github.com/cfsalguero/random_data_loader/internal/core/services/mysql"
+	postgresparser "github.com/cfsalguero/random_data_loader/internal/core/services/postgres"
+)
+
+// reportingOptions configure how the commands writing rows report their progress.
+type reportingOptions struct {
+	Progress string        `kong:"name='progress',default='auto',enum='auto,log,bar,none',help='Progress reporting (auto, log, bar, none)'"`
+	Interval time.Duration `kong:"name='progress-interval',default='5s',help='Interval between progress reports'"`
+	Metrics  string        `kong:"name='metrics-addr',help='Address to expose Prometheus metrics on, e.g. :9090'"`
+}
+
+// loadCmd loads random rows into the table, or runs workloads or clones on it.
+type loadCmd struct {
+	generationOptions `kong:"embed"`
+	reportingOptions  `kong:"embed"`
+	cleaningOptions   `kong:"embed"`
+
+	NumRows   int           `kong:"name='rows',default='10',help='Number of rows to generate'"`
+	Parallel  int           `kong:"name='parallel',default='1',help='Number of parallel processes to use'"`
+	BatchSize int           `kong:"name='batch',default='1000',help='Batch size for inserting data'"`
+	Attempts  int           `kong:"name='max-attempts',default='5',help='Maximum attempts per batch on transient errors (deadlocks, lost connections)'"`
+	Backoff   time.Duration `kong:"name='retry-backoff',default='100ms',help='Base delay of the exponential backoff between attempts'"`
+	MaxDelay  time.Duration `kong:"name='retry-max-delay',default='10s',help='Maximum delay between attempts'"`
+	CheckFile string        `kong:"name='checkpoint',help='Record the committed batches in this file so the load can be resumed'"`
+	Resume    bool          `kong:"name='resume',help='Resume the load recorded in the checkpoint file, loading only the missing batches (at-least-once)'"`
+	TargetRow int64         `kong:"name='target-rows',xor='target',help='Load rows until the table has this many rows in total, instead of --rows'"`
+	TargetSiz string        `kong:"name='target-size',xor='target',help='Load rows until the table takes this much disk space, e.g. 10GB, instead of --rows'"`
+	Duration  time.Duration `kong:"name='duration',xor='target',help='Load rows for this long, e.g. 30m, instead of --rows'"`
+	Workload  string        `kong:"name='workload',help='Run a mix of DML statements instead of loading rows, e.g. insert:60,update:30,delete:10'"`
+	UpdCols   []string      `kong:"name='update-columns',sep=',',help='Columns regenerated by workload updates, all the non key columns if not set'"`
+	CloneFrom string        `kong:"name='clone-from',help='Copy a sample of --rows rows from the database at this DSN instead of generating them'"`
+	SourceDB  string        `kong:"name='source-database',help='Source database name, --database if not set'"`
+	Where     string        `kong:"name='source-where',help='Only copy the source rows matching this SQL condition'"`
+	Mask      string        `kong:"name='mask',help='Columns to mask when cloning, e.g. email:email,customers.name:name,phone:phone'"`
+	MaskKey   string        `kong:"name='mask-key',help='Secret key of the masking hashes, random if not set'"`
+	Reads     bool          `kong:"name='reads',help='Run read queries based on the indexes and foreign keys instead of loading rows'"`
+	ReadVals  string        `kong:"name='read-values',default='live',enum='live,generated',help='Source of the read predicate values (live, generated)'"`
+	Rate      float64       `kong:"name='rate',help='Maximum insert rate in rows per second, unlimited if not set'"`
+	RampUp    time.Duration `kong:"name='ramp-up',help='Increase the rate linearly from 0 to --rate during this time'"`
+	BurstRate float64       `kong:"name='burst-rate',help='Insert rate in rows per second during bursts'"`
+	BurstEvry time.Duration `kong:"name='burst-every',help='Interval between the start of two bursts'"`
+	BurstLen  time.Duration `kong:"name='burst-length',help='Duration of every burst'"`
+	BulkSess  bool          `kong:"name='bulk-session',help='Skip the unique and foreign key checks, binary log and redo log flushes on MySQL, or the synchronous commits and triggers on Postgres, while loading'"`
+	SessVars  []string      `kong:"name='session-setting',sep='none',help='Session variable set on the worker connections while loading, e.g. unique_checks=0. Can be repeated'"`
+	GlobVars  []string      `kong:"name='global-setting',sep='none',help='MySQL global variable set while loading, e.g. innodb_flush_log_at_trx_commit=0. Can be repeated'"`
+	DropIdx   bool          `kong:"name='drop-indexes',help='Drop the secondary indexes before loading and recreate them afterwards'"`
+	DropUniq  bool          `kong:"name='drop-unique-indexes',help='Drop the unique indexes too with --drop-indexes, duplicates make their recreation fail'"`
+	IdxRecord string        `kong:"name='index-record',help='File recording the dropped indexes until they are recreated, <table>.indexes.json if not set'"`
+	IdxPar    int           `kong:"name='index-parallel',default='1',help='Number of Postgres indexes recreated at a time'"`
+	RestIdx   bool          `kong:"name='restore-indexes',help='Recreate the indexes recorded by an interrupted --drop-indexes load, without loading rows'"`
+	CreateTbl bool          `kong:"name='create-table',help='Create the table of --schema-file with its indexes and foreign keys if it does not exist'"`
+}
+
+// Run loads the rows, or runs the workload, reads or clone, and logs their stats.
+func (c *loadCmd) Run(root *cli) error {
+	settings, err := c.sessionSettings(root.DBType)
+	if err != nil {
+		return err
+	}
+
+	if c.Parallel < 1 {
+		return errors.New("--parallel must be at least 1")
+	}
+	if c.DropIdx && (c.Workload != "" || c.Reads || c.CloneFrom != "") {
+		return errors.New("--drop-indexes only applies to loads of generated rows")
+	}
+	if c.DropUniq && !c.DropIdx {
+		return errors.New("--drop-unique-indexes requires --drop-indexes")
+	}
+	if c.cleaning() && (c.Workload != "" || c.Reads || c.Resume) {
+		return errors.New("--truncate and --delete-where cannot be combined with --workload, --reads or --resume")
+	}
+	if c.CreateTbl && root.SchemaFile == "" {
+		return errors.New("--create-table requires --schema-file")
+	}
+	if err = c.checkProtected(&root.connectionOptions); err != nil {
+		return err
+	}
+
+	// The recreation of the indexes uses a connection per index
+	workers := c.Parallel
+	if c.DropIdx || c.RestIdx {
+		workers = max(workers, c.IdxPar)
+	}
+	db, tableStruct, err := root.connect(true, workers)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if c.RestIdx {
+		return c.restoreIndexes(db, root.Table)
+	}
+	if c.CreateTbl {
+		if err = createTable(context.Background(), db, root.DBType, tableStruct); err != nil {
+			return err
+		}
+	}
+
+	schedule := dataloader.RateSchedule{
+		Rate:        c.Rate,
+		RampUp:      c.RampUp,
+		BurstRate:   c.BurstRate,
+		BurstEvery:  c.BurstEvry,
+		BurstLength: c.BurstLen,
+	}
+	if rate := schedule.MinRate(); rate > 0 && float64(c.BatchSize) > rate {
+		// Batches larger than a second of rows would be inserted in bursts followed by long pauses
+		c.BatchSize = max(int(rate), 1)
+		log.Info().Int("batch", c.BatchSize).Msg("Batch size reduced to the rows inserted per second")
+	}
+
+	loader, err := c.newLoader(db, root.DBType, tableStruct, c.BatchSize, c.Parallel)
+	if err != nil {
+		return err
+	}
+	loader.Session = settings
+	loader.Retry = dataloader.RetryPolicy{
+		MaxAttempts: max(c.Attempts, 1),
+		BaseDelay:   c.Backoff,
+		MaxDelay:    c.MaxDelay,
+	}
+
+	stopMetrics := c.startReporting(loader)
+	defer stopMetrics()
+
+	if schedule.MinRate() > 0 {
+		loader.Limiter = dataloader.NewRateLimiter(schedule, c.BatchSize)
+	}
+
+	ctx := signalContext()
+
+	if err = c.clean(ctx, loader, root.Database); err != nil {
+		return err
+	}
+
+	// Workloads and clones report their own stats
+	switch {
+	case c.Workload != "":
+		err = c.runWorkload(ctx, loader, root.Table)
+	case c.Reads:
+		err = c.runReads(ctx, loader, root.Table)
+	case c.CloneFrom != "":
+		err = c.runClone(ctx, loader, root.connectionOptions)
+	default:
+		err = c.withoutIndexes(ctx, loader, root.Database, func() error {
+			return c.loadData(ctx, loader, root.Table)
+		})
+		// The rows committed before a failure are reported too
+		logSummary(loader.Stats())
+	}
+
+	if errors.Is(err, context.Canceled) {
+		if c.CheckFile != "" {
+			log.Warn().Msg("Load interrupted, run again with --resume to load the missing rows")
+		} else {
+			log.Warn().Msg("Load interrupted")
+		}
+	}
+
+	return err
+}
+
+// startReporting sets the progress reporter and the metrics of the loader. The returned function stops
+// serving the metrics.
+func (o reportingOptions) startReporting(loader *dataloader.TableDataLoader) func() {
+	loader.Progress = newProgressReporter(o.Progress, o.Interval)
+
+	if o.Metrics == "" {
+		return func() {}
+	}
+
+	var stopMetrics func()
+	loader.Metrics, stopMetrics = startMetrics(context.Background(), o.Metrics)
+
+	return stopMetrics
+}
+
+// sessionSettings returns the settings applied while loading: the bulk ones, if enabled, overridden by
+// the ones given one by one.
+func (c *loadCmd) sessionSettings(dbType string) ([]dataloader.SessionSetting, error) {
+	var settings []dataloader.SessionSetting
+	if c.BulkSess {
+		settings = dataloader.BulkSessionSettings(dbType)
+	}
+
+	add := func(values []string, global bool) error {
+		for _, value := range values {
+			setting, err := dataloader.ParseSessionSetting(value, global)
+			if err != nil {
+				return err
+			}
+			settings = slices.DeleteFunc(settings, func(s dataloader.SessionSetting) bool {
+				return s.Name == setting.Name
+			})
+			settings = append(settings, setting)
+		}
+		return nil
+	}
+	if err := add(c.SessVars, false); err != nil {
+		return nil, err
+	}
+	if err := add(c.GlobVars, true); err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+func logSummary(summary dataloader.Summary) {
+	log.Info().
+		Int64("rows", summary.RowsCommitted).
+		Int64("batches", summary.Batches).
+		Int64("errors", summary.Errors).
+		Int64("retries", summary.Retries).
+		Dur("elapsed", summary.Elapsed).
+		Msgf("Loaded %d rows in %v", summary.RowsCommitted, summary.Elapsed)
+	log.Info().Msgf("Average insertion rate: %.2f rows/sec\n", summary.Rate())
+}
+
+// runWorkload runs the mixed DML workload and logs the stats of every operation.
+func (c *loadCmd) runWorkload(ctx context.Context, loader *dataloader.TableDataLoader, table string) error {
+	mix, err := dataloader.ParseWorkloadMix(c.Workload)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Uint64("seed", loader.Seed).Msgf("Running workload %s on %s...", c.Workload, table)
+	start := time.Now()
+	stats, err := loader.RunWorkload(ctx, dataloader.WorkloadOptions{
+		Mix:           mix,
+		UpdateColumns: c.UpdCols,
+		Operations:    c.NumRows,
+		Duration:      c.Duration,
+	})
+	if err != nil {
+		return err
+	}
+	elapsed := time.Since(start)
+
+	logOperationStats("Workload", []string{dataloader.OpInsert, dataloader.OpUpdate, dataloader.OpDelete}, stats, elapsed)
+
+	return ctx.Err()
+}
+
+// runClone copies a sample of rows, and the rows they reference, from the source database to the target one,
+// with the load settings of the loader.
+func (c *loadCmd) runClone(ctx context.Context, loader *dataloader.TableDataLoader, conn connectionOptions) error {
+	key := []byte(c.MaskKey)
+	if len(key) == 0 {
+		// A random key masks the values consistently only within this run
+		key = make([]byte, 32)
+		_, _ = rand.Read(key)
+	}
+	masker, err := clone.NewMasker(key, c.Mask)
+	if err != nil {
+		return err
+	}
+
+	source, err := dbconn.Open(ctx, conn.DBType, c.CloneFrom, conn.dbconnOptions(c.Parallel))
+	if err != nil {
+		return fmt.Errorf("cannot connect to the source database: %w", err)
+	}
+	defer source.Close()
+
+	schema := c.SourceDB
+	if schema == "" {
+		schema = conn.Database
+	}
+
+	cloner := &clone.Cloner{
+		Source:    source,
+		Target:    loader.DB,
+		DBType:    conn.DBType,
+		Schema:    schema,
+		Parse:     mysqlparser.Parse,
+		Masker:    masker,
+		BatchSize: c.BatchSize,
+		Parallel:  c.Parallel,
+		Configure: func(l *dataloader.TableDataLoader) {
+			l.Session, l.Retry, l.Limiter = loader.Session, loader.Retry, loader.Limiter
+			l.Progress, l.Metrics = loader.Progress, loader.Metrics
+		},
+	}
+	if conn.DBType == "postgres" {
+		cloner.Parse = postgresparser.Parse
+	}
+
+	results, err := cloner.Clone(ctx, conn.Table, c.Where, c.NumRows)
+	for _, result := range results {
+		log.Info().
+			Int64("rows", result.Summary.RowsCommitted).
+			Int64("errors", result.Summary.Errors).
+			Dur("elapsed", result.Summary.Elapsed).
+			Msgf("Cloned %d rows into %s", result.Summary.RowsCommitted, result.Table)
+	}
+
+	return err
+}
+
+// runReads runs the read queries and logs the stats of every query shape.
+func (c *loadCmd) runReads(ctx context.Context, loader *dataloader.TableDataLoader, table string) error {
+	shapes := loader.ReadShapes()
+	names := make([]string, len(shapes))
+	for i, shape := range shapes {
+		names[i] = shape.Name
+		log.Debug().Str("query", shape.Query).Msgf("Read shape %s", shape.Name)
+	}
+
+	log.Info().Uint64("seed", loader.Seed).Msgf("Running %d read query shapes on %s...", len(shapes), table)
+	start := time.Now()
+	stats, err := loader.RunReads(ctx, dataloader.ReadOptions{
+		Values:     c.ReadVals,
+		Operations: c.NumRows,
+		Duration:   c.Duration,
+	})
+	if err != nil {
+		return err
+	}
+	logOperationStats("Read", names, stats, time.Since(start))
+
+	return ctx.Err()
+}
+
+// logOperationStats logs the counters and latencies of the named operations.
+func logOperationStats(kind string, names []string, stats dataloader.WorkloadStats, elapsed time.Duration) {
+	for _, name := range names {
+		latency := stats[name].Latency.Summary()
+		if latency.Count == 0 {
+			continue
+		}
+		log.Info().
+			Int64("count", latency.Count).
+			Int64("errors", stats[name].Errors.Load()).
+			Int64("misses", stats[name].Misses.Load()).
+			Float64("per_sec", float64(latency.Count)/elapsed.Seconds()).
+			Dur("mean", latency.Mean).
+			Dur("p50", latency.P50).
+			Dur("p95", latency.P95).
+			Dur("p99", latency.P99).
+			Dur("max", latency.Max).
+			Msgf("%s %s", kind, name)
+	}
+}
+
+// loadData loads the rows into the database, for the given duration or up to the target size if one is set.
+func (c *loadCmd) loadData(ctx context.Context, loader *dataloader.TableDataLoader, table string) error {
+	target := dataloader.Target{Rows: c.TargetRow}
+	if c.TargetSiz != "" {
+		bytes, err := parseSize(c.TargetSiz)
+		if err != nil {
+			return err
+		}
+		target.Bytes = bytes
+	}
+
+	if c.Duration > 0 {
+		if c.CheckFile != "" {
+			return errors.New("--checkpoint is not supported with --duration")
+		}
+		log.Info().Uint64("seed", loader.Seed).Msgf("Loading random rows into %s for %v...", table, c.Duration)
+		return loader.LoadFor(ctx, c.Duration, c.BatchSize)
+	}
+
+	if target != (dataloader.Target{}) {
+		if c.CheckFile != "" {
+			return errors.New("--checkpoint is not supported with --target-rows or --target-size")
+		}
+		log.Info().Int64("rows", target.Rows).Int64("bytes", target.Bytes).Uint64("seed", loader.Seed).
+			Msgf("Filling %s up to the target size...", table)
+		return loader.FillTo(ctx, target, c.BatchSize)
+	}
+
+	if err := c.setCheckpoint(loader); err != nil {
+		return err
+	}
+	log.Info().Uint64("seed", loader.Seed).Msgf("Loading %d random rows into %s...", c.NumRows, table)
+
+	return loader.LoadData(ctx, c.NumRows, c.BatchSize)
+}
+
+// withoutIndexes runs the load with the secondary indexes dropped if --drop-indexes is set. The indexes are
+// recorded before dropping them and recreated after the load, even if it is interrupted.
+func (c *loadCmd) withoutIndexes(ctx context.Context, loader *dataloader.TableDataLoader, schema string,
+	load func() error,
+) error {
+	if !c.DropIdx {
+		return load()
+	}
+
+	path := c.indexRecord(loader.TableStruct.Name)
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s records the indexes dropped by a previous load, recreate them with --restore-indexes", path)
+	}
+
+	if c.DropUniq {
+		log.Warn().Msg("Unique indexes are dropped, duplicated rows are only detected when they are recreated")
+	}
+	record, err := indexes.Capture(ctx, loader.DB, loader.DBType, schema, loader.TableStruct, c.DropUniq)
+	if err != nil {
+		return err
+	}
+	if len(record.Indexes) == 0 {
+		log.Info().Msg("No secondary indexes to drop")
+		return load()
+	}
+	if err = record.Save(path); err != nil {
+		return err
+	}
+	if err = record.Drop(ctx, loader.DB); err != nil {
+		return fmt.Errorf("%w, recreate the dropped ones with --restore-indexes", err)
+	}
+	log.Info().Int("indexes", len(record.Indexes)).Msgf("Dropped the secondary indexes, recorded in %s", path)
+
+	loadErr := load()
+
+	if err = c.recreateIndexes(context.WithoutCancel(ctx), loader.DB, record, path); err != nil {
+		return errors.Join(loadErr, err)
+	}
+
+	return loadErr
+}
+
+// createTable creates the table if it does not exist.
+func createTable(ctx context.Context, db *sql.DB, dbType string, tableStruct *domain.TableStructure) error {
+	exists, err := ddl.TableExists(ctx, db, dbType, tableStruct.Name)
+	if err != nil || exists {
+		return err
+	}
+
+	if err = ddl.Create(ctx, db, dbType, tableStruct); err != nil {
+		return err
+	}
+	log.Info().Msgf("Created table %s", tableStruct.Name)
+
+	return nil
+}
+
+// restoreIndexes recreates the indexes recorded by an interrupted load.
+func (c *loadCmd) restoreIndexes(db *sql.DB, table string) error {
+	path := c.indexRecord(table)
+	record, err := indexes.ReadRecord(path)
+	if err != nil {
+		return err
+	}
+
+	return c.recreateIndexes(context.Background(), db, record, path)
+}
+
+// recreateIndexes recreates the indexes of the record and removes its file.
+func (c *loadCmd) recreateIndexes(ctx context.Context, db *sql.DB, record *indexes.Record, path string) error {
+	log.Info().Int("indexes", len(record.Indexes)).Msgf("Recreating the secondary indexes of %s...", record.Table)
+	start := time.Now()
+	if err := record.Recreate(ctx, db, c.IdxPar); err != nil {
+		return fmt.Errorf("cannot recreate the indexes, fix the error and run again with --restore-indexes: %w", err)
+	}
+	log.Info().Dur("elapsed", time.Since(start)).Msg("Recreated the secondary indexes")
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("cannot remove the index record: %w", err)
+	}
+
+	return nil
+}
+
+// indexRecord returns the path of the index record of the table.
+func (c *loadCmd) indexRecord(table string) string {
+	if c.IdxRecord != "" {
+		return c.IdxRecord
+	}
+
+	return table + ".indexes.json"
+}
+
+// parseSize parses a size like 512MB or 10GB. Units are powers of 1024.
+func parseSize(size string) (int64, error) {
+	units := []struct {
+		suffix string
+		factor int64
+	}{
+		{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+		{"T", 1 << 40}, {"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1},
+	}
+
+	value := strings.ToUpper(strings.TrimSpace(size))
+	factor := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(value, unit.suffix) {
+			value, factor = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix)), unit.factor
+			break
+		}
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number <= 0 {
+		return 0, fmt.Errorf("invalid size: %s", size)
+	}
+
+	return int64(number * float64(factor)), nil
+}
+
+// setCheckpoint creates the checkpoint file of the load or, when resuming, reads it.
+func (c *loadCmd) setCheckpoint(loader *dataloader.TableDataLoader) error {
+	switch {
+	case c.CheckFile == "" && c.Resume:
+		return errors.New("--resume requires --checkpoint")
+	case c.CheckFile == "":
+		return nil
+	case !c.Resume:
+		return loader.StartCheckpoint(c.CheckFile, c.NumRows, c.BatchSize)
+	}
+
+	if err := loader.ResumeCheckpoint(c.CheckFile, c.NumRows, c.BatchSize); err != nil {
+		return err
+	}
+	if c.Seed != 0 && c.Seed != loader.Checkpoint.Seed {
+		return fmt.Errorf("--seed %d does not match the checkpoint seed %d", c.Seed, loader.Checkpoint.Seed)
+	}
+	log.Info().Msgf("Resuming load, %d rows already committed", loader.Checkpoint.CommittedRows())
+
+	return nil
+}
+
+// startMetrics serves the Prometheus metrics on addr until the returned function is called.
+func startMetrics(ctx context.Context, addr string) (*metrics.Metrics, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	reg := prometheus.NewRegistry()
+	m := metrics.New(reg)
+
+	go func() {
+		if err := metrics.Serve(ctx, addr, reg); err != nil {
+			log.Error().Err(err).Str("addr", addr).Msg("Metrics server failed")
+		}
+	}()
+	log.Info().Str("addr", addr).Msg("Serving Prometheus metrics on /metrics")
+
+	return m, cancel
+}
+
+// newProgressReporter returns the progress reporter for the given mode, or nil if disabled.
+func newProgressReporter(mode string, interval time.Duration) *dataloader.ProgressReporter {
+	if mode == "auto" {
+		mode = "log"
+		if isatty.IsTerminal(os.Stderr.Fd()) {
+			mode = "bar"
+		}
+	}
+
+	switch mode {
+	case "bar":
+		return dataloader.NewProgressReporter(interval, os.Stderr)
+	case "log":
+		return dataloader.NewProgressReporter(interval, nil)
+	default:
+		return nil
+	}
+}
@@ -3,78 +3,335 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/alecthomas/kong"
-	_ "github.com/go-sql-driver/mysql"
-	_ "github.com/lib/pq"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
 	"github.com/cfsalguero/random_data_loader/internal/core/dataloader"
 	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+	"github.com/cfsalguero/random_data_loader/internal/core/services/dbconn"
+	"github.com/cfsalguero/random_data_loader/internal/core/services/dsn"
 	mysqlparser "github.com/cfsalguero/random_data_loader/internal/core/services/mysql"
 	postgresparser "github.com/cfsalguero/random_data_loader/internal/core/services/postgres"
+	"github.com/cfsalguero/random_data_loader/internal/core/services/profile"
+	"github.com/cfsalguero/random_data_loader/internal/core/services/schemafile"
 )
 
-type cliOptions struct {
-	DBType    string `kong:"name='type',enum='mysql,postgres',default='mysql',required,help='Database type (mysql or postgres)'"`
-	DSN       string `kong:"name='dsn',default='root:root@tcp(localhost:3306)/my_database',required,help='Database connection string'"`
-	Database  string `kong:"name='database',default='my_database',required,help='Database schema name'"`
-	Table     string `kong:"name='table',default='test_table',required,help='Table name to parse'"`
-	NumRows   int    `kong:"name='rows',default='10',help='Number of rows to generate'"`
-	Parallel  int    `kong:"name='parallel',default='1',help='Number of parallel processes to use'"`
-	BatchSize int    `kong:"name='batch',default='1000',help='Batch size for inserting data'"`
-	LogLevel  string `kong:"name='log-level',default='info',enum='debug,info,warn,error',help='Log level (debug, info, warn, error)'"`
+// exitInterrupted is the exit code when the load is stopped by a signal, as shells do for SIGINT.
+const exitInterrupted = 130
+
+// cli holds the flags shared by every command and the commands.
+type cli struct {
+	connectionOptions `kong:"embed"`
+
+	Config   kong.ConfigFlag `kong:"name='config',help='Read the flags from this JSON or YAML file'"`
+	LogLevel string          `kong:"name='log-level',default='info',enum='debug,info,warn,error',env='RDL_LOG_LEVEL',help='Log level (debug, info, warn, error)'"`
+
+	Schema   schemaCmd   `kong:"cmd,help='Print the parsed table structure as JSON or YAML'"`
+	Plan     planCmd     `kong:"cmd,help='Print the generation plan and sample rows without writing any data'"`
+	Load     loadCmd     `kong:"cmd,default='withargs',help='Load random rows into the table (default command)'"`
+	Export   exportCmd   `kong:"cmd,help='Write random rows to a file instead of the table'"`
+	Profile  profileCmd  `kong:"cmd,help='Sample the table and write its column statistics to a profile file'"`
+	Validate validateCmd `kong:"cmd,help='Check the generation options against the live table'"`
+}
+
+// connectionOptions select the database and the table every command works on. The settings can also be
+// given in the environment or the configuration file, so the password does not show up in the process list.
+type connectionOptions struct {
+	DBType     string `kong:"name='type',enum='mysql,postgres',default='mysql',env='RDL_TYPE',help='Database type (mysql or postgres)'"`
+	DSN        string `kong:"name='dsn',env='RDL_DSN',help='Database connection string, built from the separate settings if not set'"`
+	Host       string `kong:"name='host',env='RDL_HOST',help='Database host, or MySQL socket path'"`
+	Port       int    `kong:"name='port',env='RDL_PORT',help='Database port'"`
+	User       string `kong:"name='user',env='RDL_USER',help='Database user'"`
+	Password   string `kong:"name='password',env='RDL_PASSWORD',help='Database password, better set in RDL_PASSWORD, the config file, ~/.my.cnf or ~/.pgpass'"`
+	DBName     string `kong:"name='dbname',env='RDL_DBNAME',help='Database to connect to, --database if not set'"`
+	Database   string `kong:"name='database',default='my_database',env='RDL_DATABASE',help='Database schema name'"`
+	Table      string `kong:"name='table',default='test_table',env='RDL_TABLE',help='Table name to parse'"`
+	SchemaFile string `kong:"name='schema-file',help='Read the table structure from this JSON or YAML snapshot instead of parsing it from the database'"`
+
+	TLSMode     string        `kong:"name='tls',enum=',disable,require,verify-ca,verify-full',default='',env='RDL_TLS',help='TLS mode (disable, require, verify-ca, verify-full), the one of the DSN if not set'"`
+	TLSCA       string        `kong:"name='tls-ca',env='RDL_TLS_CA',help='CA certificate to verify the server certificate with, the system CAs if not set'"`
+	TLSCert     string        `kong:"name='tls-cert',env='RDL_TLS_CERT',help='Client certificate'"`
+	TLSKey      string        `kong:"name='tls-key',env='RDL_TLS_KEY',help='Key of the client certificate'"`
+	Timeout     time.Duration `kong:"name='connect-timeout',default='10s',help='Timeout to establish a connection'"`
+	InitStmts   []string      `kong:"name='init-statement',sep='none',help='Statement to run on every new connection, e.g. SET SESSION sql_mode = TRADITIONAL. Can be repeated'"`
+	MaxOpen     int           `kong:"name='max-open-conns',help='Maximum open connections, one per parallel worker plus one if not set'"`
+	MaxIdle     int           `kong:"name='max-idle-conns',help='Maximum idle connections, --max-open-conns if not set'"`
+	MaxLifetime time.Duration `kong:"name='conn-max-lifetime',help='Close the connections after this time, e.g. to follow DNS changes, never if not set'"`
+}
+
+// generationOptions configure the generators of the commands generating rows.
+type generationOptions struct {
+	NullRatio float64 `kong:"name='null-ratio',default='0',help='Fraction of NULL values to generate for nullable columns'"`
+	Seed      uint64  `kong:"name='seed',help='Seed of the generated data, random if not set'"`
+	Profile   string  `kong:"name='profile',help='Generate values mirroring the column statistics of this profile file'"`
+	PartWts   string  `kong:"name='partition-weights',help='Distribute the rows between the partitions by weight, e.g. p2024:1,p2025:3, evenly if not set'"`
+
+	TSColumn  string        `kong:"name='time-series',help='Generate this date or timestamp column as a time series advancing with every row'"`
+	TSStart   string        `kong:"name='ts-start',default='2024-01-01 00:00:00',help='First time of the time series, in UTC unless it has an offset'"`
+	TSEvery   time.Duration `kong:"name='ts-interval',default='1s',help='Mean interval between two rows of the time series'"`
+	TSJitter  time.Duration `kong:"name='ts-jitter',help='Maximum random offset of every time series value, before or after'"`
+	TSDaily   float64       `kong:"name='ts-daily-amplitude',default='0',help='Daily variation of the time series rate, from 0 for none to 1 for no rows at the quietest hour'"`
+	TSPeak    int           `kong:"name='ts-peak-hour',default='14',help='Busiest hour of the day of the time series, in UTC'"`
+	TSWeekend float64       `kong:"name='ts-weekend-dip',default='0',help='Rate reduction of the time series on weekends, from 0 for none to 1 for no rows'"`
 }
 
 func main() {
-	var cli cliOptions
-	kong.Parse(&cli)
-	setLogger(cli.LogLevel)
+	var root cli
+	kctx := kong.Parse(&root,
+		kong.Description("Loads random rows into MySQL and PostgreSQL tables based on their structure."),
+		kong.Configuration(configLoader, defaultConfigFile))
+	setLogger(root.LogLevel)
 
-	db, err := dbConnect(cli.DBType, cli.DSN)
+	err := kctx.Run(&root)
+	if errors.Is(err, context.Canceled) {
+		os.Exit(exitInterrupted)
+	}
 	if err != nil {
-		log.Fatal().Err(err).Msg("cannot connect to the database")
+		log.Fatal().Err(err).Msgf("Failed to run %s", kctx.Command())
 	}
+}
 
-	var tableStruct *domain.TableStructure
+// connect connects to the database and returns the structure of the table, read from the schema file if set
+// or parsed from the database otherwise. Commands not writing to the database pass needDB false, so they
+// do not connect when the structure comes from the schema file and the returned database is nil. The
+// connection pool is sized for the number of workers.
+func (o *connectionOptions) connect(needDB bool, workers int) (*sql.DB, *domain.TableStructure, error) {
+	if o.SchemaFile != "" {
+		tableStruct, err := schemafile.Read(o.SchemaFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		o.Table = tableStruct.Name
+		logTableStruct(tableStruct)
+
+		if !needDB {
+			return nil, tableStruct, nil
+		}
+		db, err := o.open(workers)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return db, tableStruct, nil
+	}
+
+	db, err := o.open(workers)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tableStruct, err := o.parse(db)
+	if err != nil {
+		_ = db.Close()
+		return nil, nil, err
+	}
+
+	return db, tableStruct, nil
+}
 
+// open connects to the database, with a connection pool sized for the number of workers.
+func (o *connectionOptions) open(workers int) (*sql.DB, error) {
+	dataSource, err := o.dataSource()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := dbconn.Open(context.Background(), o.DBType, dataSource, o.dbconnOptions(workers))
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to the database: %w", err)
+	}
+
+	return db, nil
+}
+
+// dbconnOptions returns the TLS, session and pool options of the connections. Every worker holds a
+// connection, plus one for the queries run along the load, and the connections are kept idle between
+// batches instead of reconnecting.
+func (o *connectionOptions) dbconnOptions(workers int) dbconn.Options {
+	maxOpen := o.MaxOpen
+	if maxOpen == 0 {
+		maxOpen = max(workers, 1) + 1
+	}
+	maxIdle := o.MaxIdle
+	if maxIdle == 0 {
+		maxIdle = maxOpen
+	}
+
+	return dbconn.Options{
+		TLSMode:         o.TLSMode,
+		CACert:          o.TLSCA,
+		ClientCert:      o.TLSCert,
+		ClientKey:       o.TLSKey,
+		ConnectTimeout:  o.Timeout,
+		InitStatements:  o.InitStmts,
+		MaxOpenConns:    maxOpen,
+		MaxIdleConns:    maxIdle,
+		ConnMaxLifetime: o.MaxLifetime,
+	}
+}
+
+// dataSource returns the DSN given in --dsn or, if not set, the one built from the separate settings.
+// MySQL settings not given are read from ~/.my.cnf.
+func (o *connectionOptions) dataSource() (string, error) {
+	params := dsn.Params{Host: o.Host, Port: o.Port, User: o.User, Password: o.Password, DBName: o.DBName}
+	if o.DSN != "" {
+		if !params.IsZero() {
+			return "", errors.New("--dsn cannot be combined with --host, --port, --user, --password or --dbname")
+		}
+		return o.DSN, nil
+	}
+
+	if params.DBName == "" {
+		params.DBName = o.Database
+	}
+	if o.DBType == "mysql" {
+		if home, err := os.UserHomeDir(); err == nil {
+			defaults, err := dsn.ReadMyCnf(filepath.Join(home, ".my.cnf"))
+			if err != nil {
+				return "", err
+			}
+			params = params.WithDefaults(defaults)
+		}
+	}
+
+	return dsn.Build(o.DBType, params)
+}
+
+// parse parses the structure of the table from the database.
+func (o *connectionOptions) parse(db *sql.DB) (*domain.TableStructure, error) {
 	start := time.Now()
 
-	switch cli.DBType {
+	var tableStruct *domain.TableStructure
+	var err error
+	switch o.DBType {
 	case "mysql":
-		tableStruct, err = mysqlparser.Parse(db, cli.Database, cli.Table)
+		tableStruct, err = mysqlparser.Parse(db, o.Database, o.Table)
 	case "postgres":
-		tableStruct, err = postgresparser.Parse(db, cli.Database, cli.Table)
+		tableStruct, err = postgresparser.Parse(db, o.Database, o.Table)
 	}
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to parse table structure")
+		return nil, fmt.Errorf("failed to parse table structure: %w", err)
 	}
 
 	logTableStruct(tableStruct)
 
 	log.Info().Any("Parsed table structure in ", time.Since(start))
-	log.Info().Msgf("Loading %d random rows into %s...\n", cli.NumRows, cli.Table)
-	start = time.Now()
 
-	loader := dataloader.NewTableDataLoader(db, cli.DBType, tableStruct, cli.BatchSize, cli.Parallel)
+	return tableStruct, nil
+}
 
-	if err = loader.SetDefaultGenerators(); err != nil {
-		log.Fatal().Err(err).Msg("Failed to set default generators")
+// closeDB closes the database, if connected.
+func closeDB(db *sql.DB) {
+	if db != nil {
+		_ = db.Close()
+	}
+}
+
+// newLoader creates the loader of the table with the default generators, adjusted by the generation options.
+func (o generationOptions) newLoader(db *sql.DB, dbType string, tableStruct *domain.TableStructure,
+	batchSize, parallel int,
+) (*dataloader.TableDataLoader, error) {
+	loader := dataloader.NewTableDataLoader(db, dbType, tableStruct, batchSize, parallel)
+
+	if err := loader.SetDefaultGenerators(); err != nil {
+		return nil, fmt.Errorf("failed to set default generators: %w", err)
+	}
+	if o.Profile != "" {
+		if err := applyProfile(loader, o.Profile); err != nil {
+			return nil, fmt.Errorf("failed to apply the profile: %w", err)
+		}
+	}
+	loader.SetNullRatio(o.NullRatio)
+	if err := o.setPartitionGenerator(loader); err != nil {
+		return nil, err
+	}
+	if err := o.setTimeSeries(loader); err != nil {
+		return nil, err
+	}
+	if o.Seed != 0 {
+		loader.Seed = o.Seed
 	}
 
-	ctx := context.Background()
-	if err = loader.LoadData(ctx, cli.NumRows, cli.BatchSize); err != nil {
-		log.Fatal().Err(err).Msg("Failed to load data")
+	return loader, nil
+}
+
+// setPartitionGenerator constrains the partition key values to the partitions of the table, if partitioned.
+func (o generationOptions) setPartitionGenerator(loader *dataloader.TableDataLoader) error {
+	var weights map[string]int
+	if o.PartWts != "" {
+		var err error
+		if weights, err = dataloader.ParsePartitionWeights(o.PartWts); err != nil {
+			return err
+		}
 	}
 
-	log.Info().Msgf("Loaded %d rows in %v\n", cli.NumRows, time.Since(start))
-	log.Info().Msgf("Average insertion rate: %.2f rows/sec\n", float64(cli.NumRows)/time.Since(start).Seconds())
+	err := loader.SetPartitionGenerator(weights)
+	if errors.Is(err, dataloader.ErrUnconstrainedPartitionKey) {
+		log.Warn().Err(err).Msg("The partition key keeps its generator")
+		return nil
+	}
 
-	_ = db.Close()
+	return err
+}
+
+// setTimeSeries makes the --time-series column a time series.
+func (o generationOptions) setTimeSeries(loader *dataloader.TableDataLoader) error {
+	if o.TSColumn == "" {
+		return nil
+	}
+
+	var start time.Time
+	var err error
+	for _, layout := range []string{time.RFC3339Nano, time.DateTime, time.DateOnly} {
+		if start, err = time.Parse(layout, o.TSStart); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("invalid --ts-start %q, expected a date or time like 2024-01-01 00:00:00", o.TSStart)
+	}
+
+	return loader.SetTimeSeries(o.TSColumn, dataloader.TimeSeries{
+		Start:          start,
+		Interval:       o.TSEvery,
+		Jitter:         o.TSJitter,
+		DailyAmplitude: o.TSDaily,
+		PeakHour:       o.TSPeak,
+		WeekendDip:     o.TSWeekend,
+	})
+}
+
+// applyProfile replaces the default generators by generators mirroring the profile.
+func applyProfile(loader *dataloader.TableDataLoader, path string) error {
+	p, err := profile.Load(path)
+	if err != nil {
+		return err
+	}
+	log.Info().Int("sampled_rows", p.SampledRows).Msgf("Generating values from the profile %s", path)
+
+	return profile.Apply(loader, p)
+}
+
+// signalContext returns a context canceled by the first SIGINT or SIGTERM. The first signal stops the load
+// once the in-flight batches are committed, a second one kills the process.
+func signalContext() context.Context {
+	ctx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	context.AfterFunc(ctx, func() {
+		stopSignals()
+		log.Warn().Msg("Shutting down, waiting for the in-flight batches. Press Ctrl-C again to abort")
+	})
+
+	return ctx
 }
 
 func logTableStruct(tableStruct *domain.TableStructure) {
@@ -118,30 +375,6 @@ func logTableStruct(tableStruct *domain.TableStructure) {
 	}
 }
 
-func dbConnect(dbType, dsn string) (*sql.DB, error) {
-	var db *sql.DB
-	var err error
-
-	switch dbType {
-	case "mysql":
-		db, err = sql.Open("mysql", dsn)
-	case "postgres":
-		db, err = sql.Open("postgres", dsn)
-	default:
-		return nil, fmt.Errorf("unsupported database type: %s", dbType)
-	}
-
-	if err != nil {
-		return nil, err
-	}
-
-	// Test the database connection
-	if err = db.Ping(); err != nil {
-		return nil, err
-	}
-
-	return db, nil
-}
 func setLogger(level string) {
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix //nolint:reassign // Setting the default logger.
 
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/dataloader"
+)
+
+// planCmd prints how the rows would be generated.
+type planCmd struct {
+	generationOptions `kong:"embed"`
+
+	Samples int `kong:"name='sample-rows',default='5',help='Number of sample rows to print'"`
+}
+
+// Run prints the plan of the table.
+func (c *planCmd) Run(root *cli) error {
+	db, tableStruct, err := root.connect(false, 1)
+	if err != nil {
+		return err
+	}
+	defer closeDB(db)
+
+	loader, err := c.newLoader(db, root.DBType, tableStruct, 1, 1)
+	if err != nil {
+		return err
+	}
+	printPlan(loader, c.Samples)
+
+	return nil
+}
+
+// printPlan prints the generator of every column, the INSERT statement and some sample rows.
+func printPlan(loader *dataloader.TableDataLoader, samples int) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(w, "COLUMN\tTYPE\tGENERATOR\tPARAMETERS\tNULL RATIO")
+	for _, column := range loader.Plan() {
+		if column.Generator == "" {
+			_, _ = fmt.Fprintf(w, "%s\t%s\t-\tskipped: %s\t-\n", column.Column, column.DataType, column.SkipReason)
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%.2f\n",
+			column.Column, column.DataType, column.Generator, column.Params, column.NullRatio)
+	}
+	_ = w.Flush()
+
+	_, _ = fmt.Fprintf(os.Stdout, "\n%s\n\n", loader.InsertQuery())
+
+	if samples <= 0 {
+		return
+	}
+
+	names, rows := loader.SampleRows(samples)
+	_, _ = fmt.Fprintln(w, strings.Join(names, "\t"))
+	for _, row := range rows {
+		values := make([]string, len(row))
+		for i, value := range row {
+			values[i] = formatSample(value)
+		}
+		_, _ = fmt.Fprintln(w, strings.Join(values, "\t"))
+	}
+	_ = w.Flush()
+}
+
+// formatSample renders a generated value in a single short line.
+func formatSample(value any) string {
+	const maxLength = 30
+
+	var text string
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		text = `\x` + hex.EncodeToString(v)
+	case time.Time:
+		text = v.Format(time.RFC3339)
+	default:
+		text = strings.NewReplacer("\t", " ", "\n", " ").Replace(fmt.Sprint(v))
+	}
+
+	if len(text) > maxLength {
+		text = text[:maxLength-3] + "..."
+	}
+
+	return text
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/services/profile"
+)
+
+// profileCmd samples the table and writes the statistics of its columns.
+type profileCmd struct {
+	Output  string `kong:"name='output',required,help='Write the column statistics to this file'"`
+	NumRows int    `kong:"name='rows',default='10000',help='Number of rows to sample'"`
+}
+
+// Run writes the profile of the table.
+func (c *profileCmd) Run(root *cli) error {
+	db, tableStruct, err := root.connect(true, 1)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	log.Info().Int("rows", c.NumRows).Msgf("Profiling %s...", root.Table)
+	p, err := profile.Collect(context.Background(), db, root.DBType, tableStruct, c.NumRows)
+	if err != nil {
+		return err
+	}
+	if err = p.Save(c.Output); err != nil {
+		return err
+	}
+	log.Info().Int("rows", p.SampledRows).Msgf("Wrote the profile of %s to %s", root.Table, c.Output)
+
+	return nil
+}
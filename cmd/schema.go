@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+	"github.com/cfsalguero/random_data_loader/internal/core/services/ddl"
+	"github.com/cfsalguero/random_data_loader/internal/core/services/schemafile"
+)
+
+// schemaCmd prints the parsed structure of the table.
+type schemaCmd struct {
+	Format string `kong:"name='format',default='json',enum='json,yaml,sql',help='Output format (json, yaml, sql). The sql format renders the CREATE statements for --type'"`
+	Output string `kong:"name='output',help='Write the structure to this file instead of the standard output'"`
+}
+
+// Run prints the structure of the table as a snapshot that --schema-file can read back, or as the statements
+// creating it.
+func (c *schemaCmd) Run(root *cli) error {
+	db, tableStruct, err := root.connect(false, 1)
+	if err != nil {
+		return err
+	}
+	closeDB(db)
+
+	var data []byte
+	if c.Format == "sql" {
+		data, err = renderDDL(tableStruct, root.DBType)
+	} else {
+		data, err = schemafile.Encode(tableStruct, c.Format)
+	}
+	if err != nil {
+		return err
+	}
+
+	if c.Output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+
+	if err = os.WriteFile(c.Output, data, 0o600); err != nil {
+		return fmt.Errorf("cannot write the table structure: %w", err)
+	}
+
+	return nil
+}
+
+// renderDDL returns the statements creating the table in the database type, ended by semicolons.
+func renderDDL(tableStruct *domain.TableStructure, dbType string) ([]byte, error) {
+	statements, err := ddl.Render(tableStruct, dbType)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	for _, statement := range statements.All() {
+		data = append(data, statement+";\n"...)
+	}
+
+	return data, nil
+}
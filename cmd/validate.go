@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/dataloader"
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+	"github.com/cfsalguero/random_data_loader/internal/core/services/profile"
+	"github.com/cfsalguero/random_data_loader/internal/core/services/schemafile"
+)
+
+// validateCmd checks the generation options against the live table.
+type validateCmd struct {
+	generationOptions `kong:"embed"`
+
+	UpdCols []string `kong:"name='update-columns',sep=',',help='Columns regenerated by workload updates'"`
+}
+
+// Run logs the problems found, failing if there are any.
+func (c *validateCmd) Run(root *cli) error {
+	// The schema file is compared with the live table instead of replacing it
+	var snapshot *domain.TableStructure
+	if root.SchemaFile != "" {
+		var err error
+		if snapshot, err = schemafile.Read(root.SchemaFile); err != nil {
+			return err
+		}
+		root.Table = snapshot.Name
+	}
+
+	db, err := root.open(1)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tableStruct, err := root.parse(db)
+	if err != nil {
+		return err
+	}
+
+	loader, err := c.newLoader(db, root.DBType, tableStruct, 1, 1)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	if snapshot != nil {
+		for _, difference := range schemafile.Diff(snapshot, tableStruct) {
+			problems = append(problems, "the table differs from the schema file: "+difference)
+		}
+	}
+	problems = append(problems, validatePlan(loader)...)
+	problems = append(problems, validateUpdateColumns(tableStruct, c.UpdCols)...)
+	if c.Profile != "" {
+		p, loadErr := profile.Load(c.Profile)
+		if loadErr != nil {
+			return loadErr
+		}
+		problems = append(problems, validateProfile(tableStruct, p)...)
+	}
+
+	for _, fk := range tableStruct.ForeignKeys {
+		log.Warn().Msgf("%s references %s (%s), random values may not exist there",
+			strings.Join(fk.Columns, ", "), fk.ReferencedTable, strings.Join(fk.ReferencedColumns, ", "))
+	}
+	for _, problem := range problems {
+		log.Error().Msg(problem)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d problems found in the options for %s", len(problems), root.Table)
+	}
+	log.Info().Msgf("The options are valid for %s", root.Table)
+
+	return nil
+}
+
+// validatePlan returns the columns that would make the inserts fail: NOT NULL columns without a generator
+// nor a default value.
+func validatePlan(loader *dataloader.TableDataLoader) []string {
+	var problems []string
+	for i, column := range loader.Plan() {
+		structure := loader.TableStruct.Columns[i]
+		if column.Generator != "" || structure.Nullable || structure.Default != "" {
+			continue
+		}
+		if strings.Contains(column.SkipReason, "auto-increment") {
+			continue
+		}
+		problems = append(problems, fmt.Sprintf("column %s is NOT NULL without a default and is skipped: %s",
+			column.Column, column.SkipReason))
+	}
+
+	return problems
+}
+
+// validateUpdateColumns returns the update columns missing in the table or part of its primary key.
+func validateUpdateColumns(tableStruct *domain.TableStructure, columns []string) []string {
+	var primaryKey []string
+	for _, index := range tableStruct.Indexes {
+		if index.IsPrimary {
+			primaryKey = index.Columns
+		}
+	}
+
+	var problems []string
+	for _, name := range columns {
+		switch {
+		case findColumn(tableStruct, name) == nil:
+			problems = append(problems, fmt.Sprintf("update column %s is not in the table", name))
+		case slices.Contains(primaryKey, name):
+			problems = append(problems, fmt.Sprintf("update column %s is part of the primary key", name))
+		}
+	}
+
+	return problems
+}
+
+// validateProfile returns the differences between the columns of the profile and the ones of the table.
+func validateProfile(tableStruct *domain.TableStructure, p *profile.Profile) []string {
+	var problems []string
+	for _, profiled := range p.Columns {
+		column := findColumn(tableStruct, profiled.Name)
+		switch {
+		case column == nil:
+			problems = append(problems, fmt.Sprintf("profile column %s is not in the table", profiled.Name))
+		case !strings.EqualFold(column.DataType, profiled.DataType):
+			problems = append(problems, fmt.Sprintf("column %s is %s in the table but %s in the profile",
+				column.Name, column.DataType, profiled.DataType))
+		}
+	}
+
+	for _, column := range tableStruct.Columns {
+		if p.Column(column.Name) == nil {
+			log.Warn().Msgf("column %s is not in the profile, its default generator is used", column.Name)
+		}
+	}
+
+	return problems
+}
+
+func findColumn(tableStruct *domain.TableStructure, name string) *domain.TableColumn {
+	for i := range tableStruct.Columns {
+		if tableStruct.Columns[i].Name == name {
+			return &tableStruct.Columns[i]
+		}
+	}
+
+	return nil
+}
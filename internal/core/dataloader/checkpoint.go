@@ -0,0 +1,203 @@
+package dataloader
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+)
+
+// checkpointVersion is the version of the checkpoint file format.
+const checkpointVersion = 2
+
+// BatchRange is an inclusive range of batch indexes.
+type BatchRange struct {
+	First int `json:"first"`
+	Last  int `json:"last"`
+}
+
+// Checkpoint records the batches committed by the workers of a load, so an interrupted load can be resumed.
+// The file is rewritten after every committed batch, outside its transaction, so resuming is at-least-once: a
+// batch committed right before a crash, but not yet recorded, is loaded again.
+type Checkpoint struct {
+	Version   int          `json:"version"`
+	Table     string       `json:"table"`
+	PlanHash  string       `json:"plan_hash"` // Hash of the generation plan, rows and batch size
+	Seed      uint64       `json:"seed"`
+	Rows      int          `json:"rows"`
+	BatchSize int          `json:"batch_size"`
+	Batches   []BatchRange `json:"batches"` // Committed batches
+
+	path string
+	mu   sync.Mutex
+}
+
+// LoadCheckpoint reads the checkpoint file at path.
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read checkpoint: %w", err)
+	}
+
+	c := &Checkpoint{path: path}
+	if err = json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("cannot decode checkpoint %s: %w", path, err)
+	}
+	if c.Version != checkpointVersion {
+		return nil, fmt.Errorf("unsupported checkpoint version %d in %s", c.Version, path)
+	}
+
+	return c, nil
+}
+
+// StartCheckpoint creates a new checkpoint file at path for a load of numRows rows.
+func (l *TableDataLoader) StartCheckpoint(path string, numRows, batchSize int) error {
+	l.Checkpoint = &Checkpoint{
+		Version:   checkpointVersion,
+		Table:     l.TableStruct.Name,
+		PlanHash:  l.planHash(numRows, batchSize),
+		Seed:      l.Seed,
+		Rows:      numRows,
+		BatchSize: batchSize,
+		path:      path,
+	}
+
+	return l.Checkpoint.Save()
+}
+
+// ResumeCheckpoint reads the checkpoint file at path so the next load only generates the missing batches.
+// The generation plan, number of rows and batch size must match the ones of the interrupted load.
+// The loader seed is replaced by the checkpoint one.
+func (l *TableDataLoader) ResumeCheckpoint(path string, numRows, batchSize int) error {
+	c, err := LoadCheckpoint(path)
+	if err != nil {
+		return err
+	}
+
+	if c.Table != l.TableStruct.Name || c.Rows != numRows || c.BatchSize != batchSize {
+		return fmt.Errorf("checkpoint %s is for %d rows of %s in batches of %d", path, c.Rows, c.Table, c.BatchSize)
+	}
+	if c.PlanHash != l.planHash(numRows, batchSize) {
+		return fmt.Errorf("checkpoint %s was created with a different generation plan", path)
+	}
+
+	l.Seed = c.Seed
+	l.Checkpoint = c
+
+	return nil
+}
+
+// Committed reports whether the batch has already been committed. A nil checkpoint has no committed batches.
+func (c *Checkpoint) Committed(batch int) bool {
+	if c == nil {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, r := range c.Batches {
+		if batch >= r.First && batch <= r.Last {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CommittedRows returns the number of rows of all the committed batches.
+func (c *Checkpoint) CommittedRows() int {
+	if c == nil {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rows := 0
+	for _, r := range c.Batches {
+		rows += min(c.Rows, (r.Last+1)*c.BatchSize) - r.First*c.BatchSize
+	}
+
+	return rows
+}
+
+// MarkCommitted records the batch as committed and saves the checkpoint.
+func (c *Checkpoint) MarkCommitted(batch int) error {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Batches = addBatch(c.Batches, batch)
+
+	return c.save()
+}
+
+// Save writes the checkpoint to its file.
+func (c *Checkpoint) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.save()
+}
+
+// save writes the checkpoint to a temporary file and renames it, so the file is never left half written.
+func (c *Checkpoint) save() error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot encode checkpoint: %w", err)
+	}
+
+	tmp := c.path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("cannot write checkpoint: %w", err)
+	}
+	if err = os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("cannot write checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+// addBatch adds the batch to the ranges, merging the ranges that become contiguous.
+func addBatch(ranges []BatchRange, batch int) []BatchRange {
+	ranges = append(ranges, BatchRange{First: batch, Last: batch})
+	slices.SortFunc(ranges, func(a, b BatchRange) int { return cmp.Compare(a.First, b.First) })
+
+	merged := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if r.First <= last.Last+1 {
+			last.Last = max(last.Last, r.Last)
+			continue
+		}
+		merged = append(merged, r)
+	}
+
+	return merged
+}
+
+// planHash returns a hash of everything determining the generated rows except the seed.
+func (l *TableDataLoader) planHash(numRows, batchSize int) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %d %d\n", l.TableStruct.Name, numRows, batchSize)
+
+	for _, column := range l.generatedColumns() {
+		generator := l.Generators[column.Name]
+		nullRatio := 0.0
+		if nullable, ok := generator.(*NullableGenerator); ok {
+			generator, nullRatio = nullable.Generator, nullable.Ratio
+		}
+		name, params := describeGenerator(generator, 0)
+		fmt.Fprintf(h, "%s %s %s %s %v\n", column.Name, column.DataType, name, params, nullRatio)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
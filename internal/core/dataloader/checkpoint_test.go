@@ -0,0 +1,58 @@
+package dataloader_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/dataloader"
+)
+
+func TestCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "load.checkpoint")
+
+	loader := dataloader.NewTableDataLoader(nil, "mysql", planTable(), batchSize, parallel)
+	require.NoError(t, loader.SetDefaultGenerators())
+	require.NoError(t, loader.StartCheckpoint(path, 2500, 1000))
+
+	require.NoError(t, loader.Checkpoint.MarkCommitted(0))
+	require.NoError(t, loader.Checkpoint.MarkCommitted(2))
+
+	checkpoint, err := dataloader.LoadCheckpoint(path)
+	require.NoError(t, err)
+	assert.Equal(t, loader.Seed, checkpoint.Seed)
+	assert.Equal(t, []dataloader.BatchRange{{First: 0, Last: 0}, {First: 2, Last: 2}}, checkpoint.Batches)
+	assert.False(t, checkpoint.Committed(1))
+	assert.Equal(t, 1500, checkpoint.CommittedRows())
+
+	require.NoError(t, checkpoint.MarkCommitted(1))
+	assert.Equal(t, []dataloader.BatchRange{{First: 0, Last: 2}}, checkpoint.Batches)
+	assert.True(t, checkpoint.Committed(1))
+	assert.False(t, checkpoint.Committed(3))
+	assert.Equal(t, 2500, checkpoint.CommittedRows())
+}
+
+func TestResumeCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "load.checkpoint")
+
+	loader := dataloader.NewTableDataLoader(nil, "mysql", planTable(), batchSize, parallel)
+	require.NoError(t, loader.SetDefaultGenerators())
+	require.NoError(t, loader.StartCheckpoint(path, 2500, 1000))
+	_, want := loader.SampleRows(5)
+
+	resumed := dataloader.NewTableDataLoader(nil, "mysql", planTable(), batchSize, parallel)
+	require.NoError(t, resumed.SetDefaultGenerators())
+	require.NoError(t, resumed.ResumeCheckpoint(path, 2500, 1000))
+	assert.Equal(t, loader.Seed, resumed.Seed)
+
+	// The same seed generates the same rows
+	_, got := resumed.SampleRows(5)
+	assert.Equal(t, want, got)
+
+	require.Error(t, resumed.ResumeCheckpoint(path, 3000, 1000))
+
+	resumed.SetNullRatio(0.5)
+	require.Error(t, resumed.ResumeCheckpoint(path, 2500, 1000))
+}
@@ -0,0 +1,92 @@
+package dataloader
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Truncate removes all the rows of the table. Postgres also truncates the tables referencing it, with CASCADE,
+// while MySQL disables the foreign key checks, leaving the rows referencing it in place.
+func (l *TableDataLoader) Truncate(ctx context.Context) error {
+	var err error
+	if l.DBType == "postgres" {
+		_, err = l.DB.ExecContext(ctx, "TRUNCATE TABLE "+l.TableStruct.Name+" CASCADE")
+	} else {
+		_, err = l.execWithoutForeignKeyChecks(ctx, "TRUNCATE TABLE "+l.TableStruct.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot truncate %s: %w", l.TableStruct.Name, err)
+	}
+
+	return nil
+}
+
+// DeleteWhere deletes the rows matching the SQL condition and returns their number. The foreign key checks stay
+// enabled, so the ON DELETE actions of the foreign keys referencing the table apply, and the rows still
+// referenced by RESTRICT or NO ACTION foreign keys make the statement fail.
+func (l *TableDataLoader) DeleteWhere(ctx context.Context, condition string) (int64, error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE %s", l.TableStruct.Name, condition)
+	result, err := l.DB.ExecContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("cannot delete from %s: %w", l.TableStruct.Name, err)
+	}
+
+	return result.RowsAffected()
+}
+
+// CountWhere returns the number of rows matching the SQL condition.
+func (l *TableDataLoader) CountWhere(ctx context.Context, condition string) (int64, error) {
+	var count int64
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", l.TableStruct.Name, condition)
+	if err := l.DB.QueryRowContext(ctx, query).Scan(&count); err != nil {
+		return 0, fmt.Errorf("cannot count rows: %w", err)
+	}
+
+	return count, nil
+}
+
+// ReferencingTables returns the tables with a foreign key referencing the table.
+func (l *TableDataLoader) ReferencingTables(ctx context.Context) ([]string, error) {
+	var rows *sql.Rows
+	var err error
+	if l.DBType == "postgres" {
+		rows, err = l.DB.QueryContext(ctx, `
+			SELECT DISTINCT conrelid::regclass::text
+			FROM pg_constraint
+			WHERE contype = 'f' AND confrelid = to_regclass($1) AND conrelid <> confrelid`, l.TableStruct.Name)
+	} else {
+		rows, err = l.DB.QueryContext(ctx, `
+			SELECT DISTINCT TABLE_NAME
+			FROM INFORMATION_SCHEMA.KEY_COLUMN_USAGE
+			WHERE REFERENCED_TABLE_SCHEMA = DATABASE() AND REFERENCED_TABLE_NAME = ? AND TABLE_NAME <> ?`,
+			l.TableStruct.Name, l.TableStruct.Name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the referencing tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err = rows.Scan(&table); err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+
+	return tables, rows.Err()
+}
+
+// execWithoutForeignKeyChecks runs the statement on a connection with the MySQL foreign key checks disabled,
+// restoring them before returning the connection to the pool.
+func (l *TableDataLoader) execWithoutForeignKeyChecks(ctx context.Context, query string) (sql.Result, error) {
+	session := &workerSession{loader: l, settings: []SessionSetting{{Name: "foreign_key_checks", Value: "0"}}}
+	if err := session.pin(ctx); err != nil {
+		return nil, err
+	}
+	defer session.close(ctx)
+
+	return session.conn.ExecContext(ctx, query)
+}
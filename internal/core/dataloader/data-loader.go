@@ -4,11 +4,16 @@ package dataloader
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"math/rand/v2"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+	"github.com/cfsalguero/random_data_loader/internal/core/metrics"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog/log"
 )
 
@@ -20,6 +25,25 @@ type TableDataLoader struct {
 	Generators    map[string]DataGenerator
 	BatchSize     int
 	NumGoroutines int
+	Progress      *ProgressReporter // Optional, reports the progress while loading
+	Metrics       *metrics.Metrics  // Optional, Prometheus instrumentation
+	Retry         RetryPolicy
+	Seed          uint64           // Seed of the random sources of every batch
+	Checkpoint    *Checkpoint      // Optional, records the committed batches to resume the load
+	Limiter       *RateLimiter     // Optional, limits the insert rate of all the workers
+	Session       []SessionSetting // Optional, set before inserting and restored after the load
+
+	stats     *LoadStats
+	rowOffset int64 // Position of the first row of the current round, in loads made of several rounds
+}
+
+// roundBatches is the number of batches every worker loads in a round of LoadFor.
+const roundBatches = 100
+
+// Batch is a batch of generated rows. Index identifies the batch within the load.
+type Batch struct {
+	Index int
+	Rows  [][]any
 }
 
 // NewTableDataLoader creates a new table data loader.
@@ -36,19 +60,180 @@ func NewTableDataLoader(
 		Generators:    make(map[string]DataGenerator),
 		BatchSize:     batchSize,
 		NumGoroutines: parallel,
+		Retry:         DefaultRetryPolicy(),
+		Seed:          rand.Uint64(),
 	}
 }
 
+// LoadData loads numRows random rows in batches of batchSize rows. The workers take the batches in order from
+// a shared queue, skipping the ones already committed according to the checkpoint, if any.
+// Canceling the context stops the load once the batches being inserted are committed or rolled back,
+// and the context error is returned. A batch failing with a non retryable error, or once its attempts are
+// exhausted, stops its worker, and the error is returned once the other workers load the remaining batches.
 func (l *TableDataLoader) LoadData(ctx context.Context, numRows, batchSize int) error {
+	restoreSettings, err := l.setGlobalSettings(ctx)
+	if err != nil {
+		return err
+	}
+	defer restoreSettings()
+
+	l.stats = NewLoadStats(l.NumGoroutines)
+	stopProgress := l.startProgress(ctx, numRows-l.Checkpoint.CommittedRows())
+	defer stopProgress()
+
+	return l.load(ctx, numRows, batchSize)
+}
+
+// load runs the workers loading numRows rows, adding to the current stats. It returns the first worker error,
+// or the context error.
+func (l *TableDataLoader) load(parent context.Context, numRows, batchSize int) error {
+	query := l.InsertQuery()
+
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	indexes := queueBatches(ctx, l.pendingBatches(numRows, batchSize))
+
+	return l.runWorkers(parent, func(worker int) error {
+		// The generator of the worker stops with it
+		genCtx, stop := context.WithCancel(ctx)
+		defer stop()
+
+		return l.Load(ctx, query, l.generateBatches(genCtx, indexes, numRows, batchSize), worker)
+	})
+}
+
+// runWorkers runs the worker function in NumGoroutines goroutines and waits for them. It returns the first
+// worker error, or the context error.
+func (l *TableDataLoader) runWorkers(ctx context.Context, worker func(worker int) error) error {
+	errs := make([]error, l.NumGoroutines)
+	wg := &sync.WaitGroup{}
+	for i := range l.NumGoroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = worker(i)
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return ctx.Err()
+}
+
+// LoadRows inserts rows that are not generated, like rows copied from another database, in batches of
+// BatchSize rows using the loader workers. The values follow the order of the columns.
+func (l *TableDataLoader) LoadRows(ctx context.Context, columns []string, rows [][]any) error {
+	restoreSettings, err := l.setGlobalSettings(ctx)
+	if err != nil {
+		return err
+	}
+	defer restoreSettings()
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+	query := l.rebind(fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		l.TableStruct.Name, strings.Join(columns, ", "), placeholders))
+
+	l.stats = NewLoadStats(l.NumGoroutines)
+	stopProgress := l.startProgress(ctx, len(rows))
+	defer stopProgress()
+
+	loadCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	batches := make(chan Batch, l.NumGoroutines)
+	go func() {
+		defer close(batches)
+		for index := 0; index*l.BatchSize < len(rows); index++ {
+			batch := Batch{Index: index, Rows: rows[index*l.BatchSize : min((index+1)*l.BatchSize, len(rows))]}
+			select {
+			case <-loadCtx.Done():
+				return
+			case batches <- batch:
+			}
+		}
+	}()
+
+	return l.runWorkers(ctx, func(worker int) error {
+		return l.Load(loadCtx, query, batches, worker)
+	})
+}
+
+// LoadFor loads random rows in batches of batchSize rows until the duration elapses or the context is canceled.
+// The rows are loaded in rounds of a fixed number of batches per worker, every round with its own seed.
+func (l *TableDataLoader) LoadFor(ctx context.Context, duration time.Duration, batchSize int) error {
+	restoreSettings, err := l.setGlobalSettings(ctx)
+	if err != nil {
+		return err
+	}
+	defer restoreSettings()
+
+	l.stats = NewLoadStats(l.NumGoroutines)
+	total := 0
+	if l.Limiter != nil {
+		total = int(l.Limiter.Schedule.Rate * duration.Seconds())
+	}
+	stopProgress := l.startProgress(ctx, total)
+	defer stopProgress()
+
+	seed := l.Seed
+	defer func() { l.Seed, l.rowOffset = seed, 0 }()
+
+	loadCtx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	for round := 0; loadCtx.Err() == nil; round++ {
+		l.Seed = roundSeed(seed, round)
+		err := l.load(loadCtx, roundBatches*l.NumGoroutines*batchSize, batchSize)
+		if err != nil && !errors.Is(err, loadCtx.Err()) {
+			return err
+		}
+		l.rowOffset += int64(roundBatches * l.NumGoroutines * batchSize)
+	}
+
+	return ctx.Err()
+}
+
+// Stats returns the counters of the current or last load.
+func (l *TableDataLoader) Stats() Summary {
+	if l.stats == nil {
+		return Summary{}
+	}
+
+	return l.stats.Summary()
+}
+
+// startProgress starts the progress reporter, if any, and returns the function that stops it.
+func (l *TableDataLoader) startProgress(ctx context.Context, numRows int) func() {
+	if l.Progress == nil {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.Progress.Run(ctx, l.stats, int64(numRows))
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// InsertQuery returns the parameterized INSERT statement used to load the generated columns.
+func (l *TableDataLoader) InsertQuery() string {
 	// Prepare column names and placeholders for the insert statement
 	var columnNames []string
 	var placeholders []string
 
-	for _, column := range l.TableStruct.Columns {
-		// Skip columns that don't have generators
-		if _, ok := l.Generators[column.Name]; !ok {
-			continue
-		}
+	for _, column := range l.generatedColumns() {
 		columnNames = append(columnNames, column.Name)
 		placeholders = append(placeholders, "?")
 	}
@@ -60,103 +245,302 @@ func (l *TableDataLoader) LoadData(ctx context.Context, numRows, batchSize int)
 		strings.Join(placeholders, ", "),
 	)
 
-	// Replace ? with $n for PostgreSQL
-	if l.DBType == "postgres" {
-		for i := 1; i <= len(placeholders); i++ {
-			query = strings.Replace(query, "?", fmt.Sprintf("$%d", i), 1)
+	return l.rebind(query)
+}
+
+// rebind replaces the ? placeholders of the query with $n for PostgreSQL.
+func (l *TableDataLoader) rebind(query string) string {
+	if l.DBType != "postgres" {
+		return query
+	}
+
+	for i := 1; strings.Contains(query, "?"); i++ {
+		query = strings.Replace(query, "?", fmt.Sprintf("$%d", i), 1)
+	}
+
+	return query
+}
+
+// generatedColumns returns the table columns that have a generator, in table order.
+func (l *TableDataLoader) generatedColumns() []domain.TableColumn {
+	var columns []domain.TableColumn
+	for _, column := range l.TableStruct.Columns {
+		// Skip columns that don't have generators
+		if _, ok := l.Generators[column.Name]; !ok {
+			continue
 		}
+		columns = append(columns, column)
 	}
 
-	ch := l.generateRows(ctx, numRows, batchSize)
-	wg := &sync.WaitGroup{}
-	for range l.NumGoroutines {
-		wg.Add(1)
-		go l.Load(ctx, query, ch, wg)
+	return columns
+}
+
+// pendingBatches returns the indexes of the batches of the load that are not committed yet.
+func (l *TableDataLoader) pendingBatches(numRows, batchSize int) []int {
+	var pending []int
+	for index := 0; index*batchSize < numRows; index++ {
+		if !l.Checkpoint.Committed(index) {
+			pending = append(pending, index)
+		}
 	}
-	wg.Wait()
 
-	return nil
+	return pending
 }
 
-func (l *TableDataLoader) generateRows(ctx context.Context, numRows, batchSize int) chan []any {
-	ch := make(chan []any, batchSize)
+// queueBatches sends the batch indexes, in order, to the returned channel in a new goroutine. The channel is
+// closed once all the indexes are sent or the context is canceled.
+func queueBatches(ctx context.Context, indexes []int) <-chan int {
+	ch := make(chan int)
 	go func() {
-		for range numRows {
-			var values []any
-			for _, column := range l.TableStruct.Columns {
-				generator, ok := l.Generators[column.Name]
+		defer close(ch)
+		for _, index := range indexes {
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- index:
+			}
+		}
+	}()
+
+	return ch
+}
+
+// roundSeed returns the seed of a round of a load made of several rounds, so every round generates different rows.
+func roundSeed(seed uint64, round int) uint64 {
+	return rand.New(rand.NewPCG(seed, uint64(round))).Uint64()
+}
+
+// batchRand returns the random source of a batch. Every batch has its own source derived from the seed,
+// so its rows are the same regardless of the worker generating it or of the batches generated before.
+func (l *TableDataLoader) batchRand(index int) *rand.Rand {
+	return rand.New(rand.NewPCG(l.Seed, uint64(index)))
+}
+
+// generateBatches generates the batches of a load of numRows rows whose indexes are received from the channel,
+// in a new goroutine. The returned channel is closed once the indexes channel is closed or the context is canceled.
+func (l *TableDataLoader) generateBatches(ctx context.Context, indexes <-chan int, numRows, batchSize int,
+) <-chan Batch {
+	ch := make(chan Batch, 1)
+	offset := l.rowOffset
+	generator := l.newRowGenerator()
+	go func() {
+		defer close(ch)
+		for {
+			var index int
+			var ok bool
+			select {
+			case <-ctx.Done():
+				return
+			case index, ok = <-indexes:
 				if !ok {
-					continue
+					return
 				}
-				values = append(values, generator.GenerateValue())
 			}
+
+			size := min(batchSize, numRows-index*batchSize)
+			batch := Batch{Index: index, Rows: l.generateBatch(generator, index, size, offset+int64(index*batchSize))}
 			select {
 			case <-ctx.Done():
 				return
-			default:
-				ch <- values
+			case ch <- batch:
 			}
 		}
-		close(ch)
 	}()
 
 	return ch
 }
 
-//nolint:gocognit // It is more clear this way.
-func (l *TableDataLoader) Load(ctx context.Context, query string, valuesChan chan []any, wg *sync.WaitGroup) {
-	defer wg.Done()
-	tx, _ := l.DB.BeginTx(ctx, nil)
-	defer tx.Rollback()
+// rowGenerator holds the generated columns of a load with their generators and generator histograms,
+// resolved once per load.
+type rowGenerator struct {
+	columns    []domain.TableColumn
+	generators []DataGenerator
+	observers  []prometheus.Observer // nil when metrics are disabled
+}
 
-	stmt, err := tx.PrepareContext(ctx, query)
-	if err != nil {
-		log.Error().Str("worker failed to prepare statement", err.Error())
-		return
+// newRowGenerator resolves the generators and the generator histograms of the generated columns.
+func (l *TableDataLoader) newRowGenerator() *rowGenerator {
+	g := &rowGenerator{columns: l.generatedColumns()}
+	g.generators = make([]DataGenerator, len(g.columns))
+	for i, column := range g.columns {
+		g.generators[i] = l.Generators[column.Name]
+	}
+	if l.Metrics != nil {
+		g.observers = make([]prometheus.Observer, len(g.columns))
+		for i, column := range g.columns {
+			g.observers[i] = l.Metrics.GeneratorObserver(column.Name)
+		}
 	}
-	defer stmt.Close()
 
-	count := 0
-OuterLoop:
+	return g
+}
 
-	for values := range valuesChan {
-		select {
-		case <-ctx.Done():
-			break OuterLoop
-		default:
+// generateBatch generates the rows of a batch, whose first row is at the position first of the load.
+// Only the generators of the first row are timed, the clock costs as much as most generators.
+func (l *TableDataLoader) generateBatch(g *rowGenerator, index, size int, first int64) [][]any {
+	r := l.batchRand(index)
+	rows := make([][]any, size)
+	for i := range rows {
+		rows[i] = l.generateRow(r, g, first+int64(i), i == 0)
+	}
+
+	return rows
+}
+
+// generateRow generates the values of the generated columns of the row at the position, -1 if the row is not
+// part of a load. The generators are timed if timed is set and metrics are enabled.
+func (l *TableDataLoader) generateRow(r *rand.Rand, g *rowGenerator, row int64, timed bool) []any {
+	values := make([]any, len(g.columns))
+	if !timed || g.observers == nil {
+		for i, generator := range g.generators {
+			values[i] = generateValue(r, row, generator)
 		}
+		l.Metrics.RowGenerated()
+		return values
+	}
 
-		if _, err = stmt.ExecContext(ctx, values...); err != nil {
-			log.Error().Str("worker failed to execute statement", err.Error())
-			return
+	start := time.Now()
+	for i, generator := range g.generators {
+		values[i] = generateValue(r, row, generator)
+		end := time.Now()
+		g.observers[i].Observe(end.Sub(start).Seconds())
+		start = end
+	}
+	l.Metrics.RowGenerated()
+
+	return values
+}
+
+// generateValue generates a value of the row at the position, -1 if the row is not part of a load.
+func generateValue(r *rand.Rand, row int64, generator DataGenerator) any {
+	if sequence, ok := generator.(SequenceGenerator); ok && row >= 0 {
+		return sequence.GenerateValueAt(r, row)
+	}
+
+	return generator.GenerateValue(r)
+}
+
+// recordError counts a failed statement in the worker stats and the metrics.
+func (l *TableDataLoader) recordError(stats *WorkerStats, err error) {
+	stats.Errors.Add(1)
+	l.Metrics.StatementError(sqlState(err))
+}
+
+// commit commits the transaction and records the committed rows, with the time since the batch started.
+func (l *TableDataLoader) commit(tx *sql.Tx, rows int, stats *WorkerStats, start time.Time) error {
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if rows > 0 {
+		stats.RowsCommitted.Add(int64(rows))
+		stats.Batches.Add(1)
+		l.Metrics.ObserveCommit(rows, time.Since(start))
+	}
+
+	return nil
+}
+
+// Load inserts every batch received from the channel in its own transaction and records it in the checkpoint.
+// A batch failing with a transient error is replayed in a new transaction according to the retry policy.
+// Once the context is canceled no new batch is started, while the batch being inserted is still committed.
+// It returns the error of the first batch that cannot be inserted, nil if the load completes or is canceled.
+func (l *TableDataLoader) Load(ctx context.Context, query string, batches <-chan Batch, worker int) error {
+	l.Metrics.WorkerStarted()
+	defer l.Metrics.WorkerStopped()
+
+	session, err := l.newSession(ctx)
+	if err != nil {
+		return fmt.Errorf("worker %d cannot set the session settings: %w", worker, err)
+	}
+	defer session.close(ctx)
+
+	stats := l.stats.Workers[worker]
+	for batch := range batches {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if l.Limiter.Wait(ctx, len(batch.Rows)) != nil {
+			// Canceled while waiting for the rate limiter
+			return nil
 		}
 
-		count++
-		if count >= l.BatchSize {
-			if err = tx.Commit(); err != nil {
-				log.Error().Str("worker failed to commit transaction", err.Error())
-				return
+		if err := l.insertBatch(ctx, session, query, batch.Rows, stats); err != nil {
+			if ctx.Err() != nil {
+				log.Warn().Err(err).Int("batch", batch.Index).Msg("batch rolled back on shutdown")
+				return nil
 			}
+			return fmt.Errorf("cannot insert batch %d: %w", batch.Index, err)
+		}
 
-			tx, err = l.DB.BeginTx(ctx, nil)
-			if err != nil {
-				log.Error().Str("worker failed to begin transaction", err.Error())
-				return
-			}
+		if err := l.Checkpoint.MarkCommitted(batch.Index); err != nil {
+			return fmt.Errorf("cannot save the checkpoint of batch %d: %w", batch.Index, err)
+		}
+	}
 
-			stmt, err = tx.PrepareContext(ctx, query)
-			if err != nil {
-				log.Error().Str("worker failed to prepare statement", err.Error())
-				return
-			}
+	return nil
+}
+
+// insertBatch inserts the rows in a single transaction on the worker session, retrying on transient errors.
+// The transaction is not bound to the context cancellation, so a shutdown does not abort a batch half way;
+// retries stop once the context is canceled. A pinned connection is replaced after a failed attempt.
+func (l *TableDataLoader) insertBatch(ctx context.Context, session *workerSession, query string, batch [][]any,
+	stats *WorkerStats,
+) error {
+	txCtx := context.WithoutCancel(ctx)
+	for attempt := 1; ; attempt++ {
+		err := session.pin(txCtx)
+		if err == nil {
+			err = l.execBatch(txCtx, session.db(), query, batch, stats)
+		}
+		if err == nil {
+			return nil
+		}
+
+		session.drop()
+		l.recordError(stats, err)
+		if !IsRetryable(err) || attempt >= l.Retry.MaxAttempts || ctx.Err() != nil {
+			return err
+		}
+
+		delay := l.Retry.Backoff(attempt)
+		log.Warn().Err(err).Int("attempt", attempt).Dur("delay", delay).Msg("retrying batch")
+		stats.Retries.Add(1)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
 
-			count = 0
+// execBatch inserts the rows using a new transaction and prepared statement.
+func (l *TableDataLoader) execBatch(ctx context.Context, db txBeginner, query string, batch [][]any,
+	stats *WorkerStats,
+) error {
+	start := time.Now()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("cannot begin transaction: %w", err)
+	}
+	defer tx.Rollback() //nolint:errcheck // Rollback after commit is a no-op.
+
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("cannot prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, values := range batch {
+		if _, err = stmt.ExecContext(ctx, values...); err != nil {
+			return fmt.Errorf("cannot execute statement: %w", err)
 		}
 	}
 
-	//if count > 0 {
-	if err = tx.Commit(); err != nil {
-		log.Error().Str("worker failed to commit transaction", err.Error())
+	if err = l.commit(tx, len(batch), stats, start); err != nil {
+		return fmt.Errorf("cannot commit transaction: %w", err)
 	}
-	//}
+
+	return nil
 }
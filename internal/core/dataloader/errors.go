@@ -0,0 +1,79 @@
+package dataloader
+
+import (
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+// sqlState returns the SQLSTATE code of a database error, or "unknown" for errors not coming from the server.
+// MySQL errors with the generic HY000 state are reported by their error number.
+func sqlState(err error) string {
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) {
+		state := string(myErr.SQLState[:])
+		if state == "" || state == "\x00\x00\x00\x00\x00" || state == "HY000" {
+			return strconv.Itoa(int(myErr.Number))
+		}
+		return state
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return string(pqErr.Code)
+	}
+
+	return "unknown"
+}
+
+// IsRetryable reports whether the error is transient, so the failed batch can be replayed in a new transaction.
+// Deadlocks, lock wait timeouts, serialization failures and lost connections are retryable.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) {
+		switch myErr.Number {
+		case 1205, // ER_LOCK_WAIT_TIMEOUT
+			1213, // ER_LOCK_DEADLOCK
+			1040, // ER_CON_COUNT_ERROR
+			1053: // ER_SERVER_SHUTDOWN
+			return true
+		}
+		return false
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		code := string(pqErr.Code)
+		switch code {
+		case "40001", // serialization_failure
+			"40P01", // deadlock_detected
+			"53300", // too_many_connections
+			"57P01", // admin_shutdown
+			"57P02", // crash_shutdown
+			"57P03": // cannot_connect_now
+			return true
+		}
+		// Class 08, connection exceptions
+		return strings.HasPrefix(code, "08")
+	}
+
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, mysql.ErrInvalidConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.As(err, &netErr)
+}
@@ -1,6 +1,7 @@
 package dataloader
 
 import (
+	"encoding/binary"
 	"fmt"
 	"math"
 	"math/rand/v2"
@@ -12,8 +13,9 @@ import (
 )
 
 // DataGenerator defines the interface for generating random data for a specific column.
+// Generators draw all their randomness from r, so the same source always produces the same values.
 type DataGenerator interface {
-	GenerateValue() interface{}
+	GenerateValue(r *rand.Rand) interface{}
 }
 
 // StringGenerator generates random string values.
@@ -31,10 +33,10 @@ func NewStringGenerator(length int) *StringGenerator {
 }
 
 // GenerateValue generates a random string.
-func (g *StringGenerator) GenerateValue() interface{} {
+func (g *StringGenerator) GenerateValue(r *rand.Rand) interface{} {
 	result := make([]byte, g.Length)
 	for i := range result {
-		result[i] = g.Chars[rand.IntN(len(g.Chars))]
+		result[i] = g.Chars[r.IntN(len(g.Chars))]
 	}
 	return string(result)
 }
@@ -54,8 +56,8 @@ func NewIntGenerator(min, max int64) *IntGenerator {
 }
 
 // GenerateValue generates a random integer.
-func (g *IntGenerator) GenerateValue() interface{} {
-	return g.Min + int64(rand.IntN(int(g.Max-g.Min+1)))
+func (g *IntGenerator) GenerateValue(r *rand.Rand) interface{} {
+	return g.Min + int64(r.IntN(int(g.Max-g.Min+1)))
 }
 
 // FloatGenerator generates random float values.
@@ -75,8 +77,8 @@ func NewFloatGenerator(min, max float64, prec int) *FloatGenerator {
 }
 
 // GenerateValue generates a random float.
-func (g *FloatGenerator) GenerateValue() interface{} {
-	val := g.Min + rand.Float64()*(g.Max-g.Min)
+func (g *FloatGenerator) GenerateValue(r *rand.Rand) interface{} {
+	val := g.Min + r.Float64()*(g.Max-g.Min)
 	// Scale for precision
 	scale := float64(1)
 	for range g.Prec {
@@ -94,8 +96,8 @@ func NewBoolGenerator() *BoolGenerator {
 }
 
 // GenerateValue generates a random boolean.
-func (g *BoolGenerator) GenerateValue() interface{} {
-	return rand.IntN(2) == 1
+func (g *BoolGenerator) GenerateValue(r *rand.Rand) interface{} {
+	return r.IntN(2) == 1
 }
 
 // DateGenerator generates random date values.
@@ -113,9 +115,9 @@ func NewDateGenerator(start, end time.Time) *DateGenerator {
 }
 
 // GenerateValue generates a random date.
-func (g *DateGenerator) GenerateValue() interface{} {
+func (g *DateGenerator) GenerateValue(r *rand.Rand) interface{} {
 	delta := g.End.Unix() - g.Start.Unix()
-	sec := int64(rand.IntN(int(delta))) + g.Start.Unix()
+	sec := int64(r.IntN(int(delta))) + g.Start.Unix()
 	return time.Unix(sec, 0)
 }
 
@@ -136,10 +138,10 @@ func NewTimestampGenerator(start, end time.Time, withTZ bool) *TimestampGenerato
 }
 
 // GenerateValue generates a random timestamp.
-func (g *TimestampGenerator) GenerateValue() interface{} {
+func (g *TimestampGenerator) GenerateValue(r *rand.Rand) interface{} {
 	delta := g.End.Unix() - g.Start.Unix()
-	sec := int64(rand.IntN(int(delta))) + g.Start.Unix()
-	nsec := int64(rand.IntN(1000000000))
+	sec := int64(r.IntN(int(delta))) + g.Start.Unix()
+	nsec := int64(r.IntN(1000000000))
 	ts := time.Unix(sec, nsec)
 	if g.WithTZ {
 		return ts
@@ -160,8 +162,8 @@ func NewEnumGenerator(values []string) *EnumGenerator {
 }
 
 // GenerateValue generates a random enum value.
-func (g *EnumGenerator) GenerateValue() interface{} {
-	return g.Values[rand.IntN(len(g.Values))]
+func (g *EnumGenerator) GenerateValue(r *rand.Rand) interface{} {
+	return g.Values[r.IntN(len(g.Values))]
 }
 
 // JSONGenerator generates random JSON objects.
@@ -183,8 +185,8 @@ func NewJSONGenerator(fields, depth, arrayItems int, format string) *JSONGenerat
 }
 
 // GenerateValue generates a random JSON object.
-func (g *JSONGenerator) GenerateValue() interface{} {
-	json := g.generateObject(0)
+func (g *JSONGenerator) GenerateValue(r *rand.Rand) interface{} {
+	json := g.generateObject(r, 0)
 	if g.Format == "postgres" {
 		return json
 	}
@@ -193,41 +195,41 @@ func (g *JSONGenerator) GenerateValue() interface{} {
 }
 
 // generateObject creates a random JSON object with the specified depth.
-func (g *JSONGenerator) generateObject(depth int) string {
+func (g *JSONGenerator) generateObject(r *rand.Rand, depth int) string {
 	if depth >= g.Depth {
-		return `"leaf_value_` + randomString(5) + `"`
+		return `"leaf_value_` + randomString(r, 5) + `"`
 	}
 
-	fields := rand.IntN(g.Fields) + 1
+	fields := r.IntN(g.Fields) + 1
 	parts := make([]string, fields)
 
 	for i := range fields {
-		key := "key_" + randomString(3)
+		key := "key_" + randomString(r, 3)
 		var value string
 
-		switch rand.IntN(4) {
+		switch r.IntN(4) {
 		case 0:
 			// String
-			value = `"value_` + randomString(5) + `"`
+			value = `"value_` + randomString(r, 5) + `"`
 		case 1:
 			// Number
-			value = strconv.Itoa(rand.IntN(1000))
+			value = strconv.Itoa(r.IntN(1000))
 		case 2:
 			// Object (if not too deep)
 			if depth < g.Depth-1 {
-				value = g.generateObject(depth + 1)
+				value = g.generateObject(r, depth+1)
 			} else {
-				value = `"leaf_value_` + randomString(5) + `"`
+				value = `"leaf_value_` + randomString(r, 5) + `"`
 			}
 		case 3:
 			// Array
-			items := rand.IntN(g.ArrayItems) + 1
+			items := r.IntN(g.ArrayItems) + 1
 			elements := make([]string, items)
 			for j := range items {
-				if depth < g.Depth-1 && rand.IntN(2) == 0 {
-					elements[j] = g.generateObject(depth + 1)
+				if depth < g.Depth-1 && r.IntN(2) == 0 {
+					elements[j] = g.generateObject(r, depth+1)
 				} else {
-					elements[j] = `"item_` + randomString(3) + `"`
+					elements[j] = `"item_` + randomString(r, 3) + `"`
 				}
 			}
 			value = "[" + strings.Join(elements, ", ") + "]"
@@ -248,8 +250,14 @@ func NewUUIDGenerator() *UUIDGenerator {
 }
 
 // GenerateValue generates a random UUID.
-func (g *UUIDGenerator) GenerateValue() interface{} {
-	return uuid.NewString()
+func (g *UUIDGenerator) GenerateValue(r *rand.Rand) interface{} {
+	var id uuid.UUID
+	binary.BigEndian.PutUint64(id[:8], r.Uint64())
+	binary.BigEndian.PutUint64(id[8:], r.Uint64())
+	id[6] = (id[6] & 0x0f) | 0x40 // Version 4
+	id[8] = (id[8] & 0x3f) | 0x80 // RFC 4122 variant
+
+	return id.String()
 }
 
 // IPGenerator generates random IP addresses.
@@ -265,20 +273,20 @@ func NewIPGenerator(ipv6 bool) *IPGenerator {
 }
 
 // GenerateValue generates a random IP address.
-func (g *IPGenerator) GenerateValue() interface{} {
+func (g *IPGenerator) GenerateValue(r *rand.Rand) interface{} {
 	if g.IPv6 {
 		// Generate IPv6
 		parts := make([]string, 8)
 		for i := range parts {
-			parts[i] = fmt.Sprintf("%x", rand.IntN(65536))
+			parts[i] = fmt.Sprintf("%x", r.IntN(65536))
 		}
 		return strings.Join(parts, ":")
 	}
 
 	// Generate IPv4
 	return fmt.Sprintf("%d.%d.%d.%d",
-		rand.IntN(256), rand.IntN(256),
-		rand.IntN(256), rand.IntN(256))
+		r.IntN(256), r.IntN(256),
+		r.IntN(256), r.IntN(256))
 }
 
 // BinaryGenerator generates random binary data.
@@ -293,11 +301,11 @@ func NewBinaryGenerator(length int) *BinaryGenerator {
 	}
 }
 
-func (g *BinaryGenerator) GenerateValue() interface{} {
+func (g *BinaryGenerator) GenerateValue(r *rand.Rand) interface{} {
 	// GenerateValue generates random binary data.
 	data := make([]byte, 0, g.Length)
 	for range data {
-		data = append(data, byte(rand.IntN(256))) // Random byte
+		data = append(data, byte(r.IntN(256))) // Random byte
 	}
 
 	return data
@@ -316,36 +324,36 @@ func NewGeometryGenerator(geomType string) *GeometryGenerator {
 }
 
 // GenerateValue generates a random geometry in WKT format.
-func (g *GeometryGenerator) GenerateValue() interface{} {
+func (g *GeometryGenerator) GenerateValue(r *rand.Rand) interface{} {
 	switch g.Type {
 	case "point":
-		x := rand.Float64()*360 - 180 // longitude: -180 to 180
-		y := rand.Float64()*180 - 90  // latitude: -90 to 90
+		x := r.Float64()*360 - 180 // longitude: -180 to 180
+		y := r.Float64()*180 - 90  // latitude: -90 to 90
 		return fmt.Sprintf("POINT(%f %f)", x, y)
 
 	case "linestring":
-		points := rand.IntN(3) + 2 // At least 2 points
+		points := r.IntN(3) + 2 // At least 2 points
 		parts := make([]string, points)
 		for i := range points {
-			x := rand.Float64()*360 - 180
-			y := rand.Float64()*180 - 90
+			x := r.Float64()*360 - 180
+			y := r.Float64()*180 - 90
 			parts[i] = fmt.Sprintf("%f %f", x, y)
 		}
 		return fmt.Sprintf("LINESTRING(%s)", strings.Join(parts, ", "))
 
 	case "polygon":
-		points := rand.IntN(3) + 4 // At least 4 points for a closed polygon
+		points := r.IntN(3) + 4 // At least 4 points for a closed polygon
 		parts := make([]string, points)
 
 		// Generate a rough circle-like polygon
-		centerX := rand.Float64()*360 - 180
-		centerY := rand.Float64()*180 - 90
-		radius := rand.Float64() * 10
+		centerX := r.Float64()*360 - 180
+		centerY := r.Float64()*180 - 90
+		radius := r.Float64() * 10
 
 		for i := range points - 1 {
 			angle := 2 * float64(i) * 3.14159 / float64(points-1)
-			x := centerX + radius*0.5*float64(rand.IntN(10)+5)*0.1*float64(math.Cos(angle))
-			y := centerY + radius*0.5*float64(rand.IntN(10)+5)*0.1*float64(math.Sin(angle))
+			x := centerX + radius*0.5*float64(r.IntN(10)+5)*0.1*float64(math.Cos(angle))
+			y := centerY + radius*0.5*float64(r.IntN(10)+5)*0.1*float64(math.Sin(angle))
 			parts[i] = fmt.Sprintf("%f %f", x, y)
 		}
 		// Close the polygon by repeating the first poislognt
@@ -373,8 +381,8 @@ func NewMoneyGenerator(min, max float64) *MoneyGenerator {
 }
 
 // GenerateValue generates a random monetary value.
-func (g *MoneyGenerator) GenerateValue() interface{} {
-	value := g.Min + rand.Float64()*(g.Max-g.Min)
+func (g *MoneyGenerator) GenerateValue(r *rand.Rand) interface{} {
+	value := g.Min + r.Float64()*(g.Max-g.Min)
 	// Format with 2 decimal places
 	return fmt.Sprintf("%.2f", value)
 }
@@ -394,10 +402,10 @@ func NewIntervalGenerator(minHours, maxHours int) *IntervalGenerator {
 }
 
 // GenerateValue generates a random interval.
-func (g *IntervalGenerator) GenerateValue() interface{} {
-	hours := g.MinHours + rand.IntN(g.MaxHours-g.MinHours+1)
-	minutes := rand.IntN(60)
-	seconds := rand.IntN(60)
+func (g *IntervalGenerator) GenerateValue(r *rand.Rand) interface{} {
+	hours := g.MinHours + r.IntN(g.MaxHours-g.MinHours+1)
+	minutes := r.IntN(60)
+	seconds := r.IntN(60)
 
 	return fmt.Sprintf("%d hours %d minutes %d seconds", hours, minutes, seconds)
 }
@@ -415,10 +423,10 @@ func NewBitStringGenerator(length int) *BitStringGenerator {
 }
 
 // GenerateValue generates a random bit string.
-func (g *BitStringGenerator) GenerateValue() interface{} {
+func (g *BitStringGenerator) GenerateValue(r *rand.Rand) interface{} {
 	bits := make([]byte, g.Length)
 	for i := range bits {
-		if rand.IntN(2) == 1 {
+		if r.IntN(2) == 1 {
 			bits[i] = '1'
 		} else {
 			bits[i] = '0'
@@ -427,12 +435,34 @@ func (g *BitStringGenerator) GenerateValue() interface{} {
 	return string(bits)
 }
 
+// NullableGenerator returns NULL for a fraction of the values and delegates to another generator otherwise.
+type NullableGenerator struct {
+	Generator DataGenerator
+	Ratio     float64 // Fraction of NULL values, between 0 and 1
+}
+
+// NewNullableGenerator creates a new generator returning NULL with the given ratio.
+func NewNullableGenerator(generator DataGenerator, ratio float64) *NullableGenerator {
+	return &NullableGenerator{
+		Generator: generator,
+		Ratio:     ratio,
+	}
+}
+
+// GenerateValue generates NULL or a value from the wrapped generator.
+func (g *NullableGenerator) GenerateValue(r *rand.Rand) interface{} {
+	if r.Float64() < g.Ratio {
+		return nil
+	}
+	return g.Generator.GenerateValue(r)
+}
+
 // Helper function to generate random strings.
-func randomString(length int) string {
+func randomString(r *rand.Rand, length int) string {
 	const chars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 	result := make([]byte, length)
 	for i := range result {
-		result[i] = chars[rand.IntN(len(chars))]
+		result[i] = chars[r.IntN(len(chars))]
 	}
 	return string(result)
 }
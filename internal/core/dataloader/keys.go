@@ -0,0 +1,338 @@
+package dataloader
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// keyPoolSize is the number of keys fetched at once for keys that are not a single integer column.
+	keyPoolSize = 1000
+	// keyRangeRefresh is the number of keys drawn between two refreshes of an integer key range.
+	keyRangeRefresh = 1000
+	// keySampleFactor is how many more rows than the pool size a Postgres table sample is expected to return,
+	// as SYSTEM samples whole pages and the row estimate may be stale.
+	keySampleFactor = 4
+	// noKeysBackoff is how long a worker waits before sampling the keys of an empty table again.
+	noKeysBackoff = 100 * time.Millisecond
+)
+
+// ErrNoKeys is returned when a key is sampled from an empty table.
+var ErrNoKeys = errors.New("the table has no rows to sample keys from")
+
+// keySampler draws key values of existing rows from the live table.
+// Single integer column keys are drawn uniformly between the current minimum and maximum, so values of
+// deleted rows are possible, unless the range does not fit in an int64. Other keys are taken from a pool of
+// rows sampled without sorting the table: on Postgres a TABLESAMPLE of random pages, on MySQL the keys
+// following a random value of the first column through its index.
+type keySampler struct {
+	db         *sql.DB
+	postgres   bool
+	table      string
+	columns    []string
+	integer    bool
+	rangeQuery string // Query refreshing the range of integer keys
+	query      string // Query refreshing the pool
+	wrapQuery  string // Query reading the keys before the pivot on MySQL
+
+	mu       sync.Mutex
+	min, max int64
+	drawn    int
+	pool     [][]any
+}
+
+// sleep waits for the duration or until the context is done.
+func sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// primaryKey returns the columns of the primary key, if any.
+func (l *TableDataLoader) primaryKey() []string {
+	for _, index := range l.TableStruct.Indexes {
+		if index.IsPrimary {
+			return index.Columns
+		}
+	}
+
+	return nil
+}
+
+// newKeySampler creates a sampler for the values of the given key columns.
+func (l *TableDataLoader) newKeySampler(columns []string) *keySampler {
+	s := &keySampler{db: l.DB, postgres: l.DBType == "postgres", table: l.TableStruct.Name, columns: columns}
+	list := strings.Join(columns, ", ")
+
+	if len(columns) == 1 && isIntegerType(l.columnType(columns[0])) {
+		s.integer = true
+		s.rangeQuery = fmt.Sprintf("SELECT MIN(%[1]s), MAX(%[1]s) FROM %[2]s", columns[0], s.table)
+	}
+	if s.postgres {
+		s.query = fmt.Sprintf("SELECT %s FROM %s TABLESAMPLE SYSTEM ($1) LIMIT %d", list, s.table, keyPoolSize)
+	} else {
+		s.query = fmt.Sprintf("SELECT %s FROM %s WHERE %s >= ? ORDER BY %s LIMIT %d",
+			list, s.table, columns[0], list, keyPoolSize)
+		s.wrapQuery = fmt.Sprintf("SELECT %s FROM %s WHERE %s < ? ORDER BY %s LIMIT %d",
+			list, s.table, columns[0], list, keyPoolSize)
+	}
+
+	return s
+}
+
+// columnType returns the data type of a column, or an empty string if the table has no such column.
+func (l *TableDataLoader) columnType(name string) string {
+	for _, column := range l.TableStruct.Columns {
+		if column.Name == name {
+			return column.DataType
+		}
+	}
+
+	return ""
+}
+
+// Sample returns the values of the key columns of an existing row.
+// Pooled keys are removed from the pool, so the same key is not returned twice until the next refresh.
+func (s *keySampler) Sample(ctx context.Context, r *rand.Rand) ([]any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.integer && s.drawn%keyRangeRefresh == 0 {
+		if err := s.refreshRange(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if s.integer {
+		s.drawn++
+
+		return []any{s.min + r.Int64N(s.max-s.min+1)}, nil
+	}
+
+	if len(s.pool) == 0 {
+		if err := s.refreshPool(ctx, r); err != nil {
+			return nil, err
+		}
+	}
+	key := s.pool[len(s.pool)-1]
+	s.pool = s.pool[:len(s.pool)-1]
+
+	return key, nil
+}
+
+func (s *keySampler) refreshRange(ctx context.Context) error {
+	// Scanned as strings, as unsigned bigint values above the int64 range cannot be scanned as integers
+	var low, high sql.NullString
+	if err := s.db.QueryRowContext(ctx, s.rangeQuery).Scan(&low, &high); err != nil {
+		return fmt.Errorf("cannot sample keys: %w", err)
+	}
+	if !low.Valid || !high.Valid {
+		return ErrNoKeys
+	}
+
+	minValue, minErr := strconv.ParseInt(low.String, 10, 64)
+	maxValue, maxErr := strconv.ParseInt(high.String, 10, 64)
+	if minErr != nil || maxErr != nil || maxValue-minValue < 0 || maxValue-minValue == math.MaxInt64 {
+		// The range does not fit in an int64, the keys are sampled from the pool instead
+		s.integer = false
+		return nil
+	}
+	s.min, s.max = minValue, maxValue
+
+	return nil
+}
+
+// refreshPool fills the pool with sampled keys, in random order.
+func (s *keySampler) refreshPool(ctx context.Context, r *rand.Rand) error {
+	var err error
+	if s.postgres {
+		err = s.samplePages(ctx)
+	} else {
+		err = s.sampleIndex(ctx, r)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot sample keys: %w", err)
+	}
+	if len(s.pool) == 0 {
+		return ErrNoKeys
+	}
+	r.Shuffle(len(s.pool), func(i, j int) { s.pool[i], s.pool[j] = s.pool[j], s.pool[i] })
+
+	return nil
+}
+
+// samplePages samples the pages of a Postgres table, in the proportion expected to hold a few times the pool
+// size according to the row estimate. Small or never analyzed tables are sampled in full.
+func (s *keySampler) samplePages(ctx context.Context) error {
+	var rows float64
+	err := s.db.QueryRowContext(ctx, "SELECT reltuples FROM pg_class WHERE oid = to_regclass($1)", s.table).
+		Scan(&rows)
+	if err != nil {
+		return err
+	}
+
+	percent := 100.0
+	if rows > 0 {
+		percent = min(100*keySampleFactor*keyPoolSize/rows, 100)
+	}
+	if err = s.fill(ctx, s.query, percent); err != nil {
+		return err
+	}
+	if len(s.pool) == 0 && percent < 100 {
+		// No sampled page had rows, the LIMIT stops the full sample early
+		return s.fill(ctx, s.query, 100)
+	}
+
+	return nil
+}
+
+// sampleIndex reads the keys following a random value of the first key column, between its lowest and
+// highest values, wrapping around to the lowest ones when there are not enough keys after it.
+func (s *keySampler) sampleIndex(ctx context.Context, r *rand.Rand) error {
+	bound := "SELECT %[1]s FROM %[2]s WHERE %[1]s IS NOT NULL ORDER BY %[1]s %[3]s LIMIT 1"
+	var low, high any
+	err := s.db.QueryRowContext(ctx, fmt.Sprintf(bound, s.columns[0], s.table, "ASC")).Scan(&low)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err = s.db.QueryRowContext(ctx, fmt.Sprintf(bound, s.columns[0], s.table, "DESC")).Scan(&high); err != nil {
+		return err
+	}
+
+	start := pivot(r, low, high)
+	if err = s.fill(ctx, s.query, start); err != nil {
+		return err
+	}
+	if len(s.pool) < keyPoolSize {
+		return s.fill(ctx, s.wrapQuery, start)
+	}
+
+	return nil
+}
+
+// fill adds the keys returned by the query to the pool, up to the pool size.
+func (s *keySampler) fill(ctx context.Context, query string, args ...any) error {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() && len(s.pool) < keyPoolSize {
+		key := make([]any, len(s.columns))
+		pointers := make([]any, len(key))
+		for i := range key {
+			pointers[i] = &key[i]
+		}
+		if err = rows.Scan(pointers...); err != nil {
+			return err
+		}
+		s.pool = append(s.pool, key)
+	}
+
+	return rows.Err()
+}
+
+// pivot returns a random value between the low and high values of a column, as returned by the driver:
+// numbers, times, or the text of any other type. Text values are interpolated as printable ASCII after their
+// common prefix, so the pivot is valid text, and other values of different types are not interpolated.
+func pivot(r *rand.Rand, low, high any) any {
+	switch lo := low.(type) {
+	case int64:
+		if hi, ok := high.(int64); ok && hi >= lo {
+			return lo + r.Int64N(hi-lo+1)
+		}
+	case uint64:
+		if hi, ok := high.(uint64); ok && hi >= lo {
+			return lo + r.Uint64N(hi-lo+1)
+		}
+	case float64:
+		if hi, ok := high.(float64); ok && hi >= lo {
+			return lo + r.Float64()*(hi-lo)
+		}
+	case time.Time:
+		if hi, ok := high.(time.Time); ok && hi.After(lo) {
+			return lo.Add(time.Duration(r.Int64N(int64(hi.Sub(lo)))))
+		}
+	case []byte:
+		if hi, ok := high.([]byte); ok {
+			return textPivot(r, string(lo), string(hi))
+		}
+	case string:
+		if hi, ok := high.(string); ok {
+			return textPivot(r, lo, hi)
+		}
+	}
+
+	return low
+}
+
+// textPivotLength is the number of characters interpolated after the common prefix of two text values.
+const textPivotLength = 8
+
+// textPivot returns a random text value between the low and high ones. Integer, decimal, date and time values
+// are interpolated as such.
+func textPivot(r *rand.Rand, low, high string) string {
+	if lo, err := strconv.ParseInt(low, 10, 64); err == nil {
+		if hi, err := strconv.ParseInt(high, 10, 64); err == nil && hi >= lo {
+			return strconv.FormatInt(lo+r.Int64N(hi-lo+1), 10)
+		}
+	}
+	if lo, err := strconv.ParseFloat(low, 64); err == nil {
+		if hi, err := strconv.ParseFloat(high, 64); err == nil && hi >= lo {
+			return strconv.FormatFloat(lo+r.Float64()*(hi-lo), 'f', -1, 64)
+		}
+	}
+	for _, layout := range []string{time.DateTime, time.DateOnly} {
+		lo, loErr := time.Parse(layout, low)
+		hi, hiErr := time.Parse(layout, high)
+		if loErr == nil && hiErr == nil && hi.After(lo) {
+			return lo.Add(time.Duration(r.Int64N(int64(hi.Sub(lo))))).Format(layout)
+		}
+	}
+
+	prefix := 0
+	for prefix < min(len(low), len(high)) && low[prefix] == high[prefix] {
+		prefix++
+	}
+
+	// The characters after the prefix as numbers in base 95, the printable ASCII characters
+	digits := func(s string) uint64 {
+		n := uint64(0)
+		for i := range textPivotLength {
+			c := byte(' ')
+			if prefix+i < len(s) {
+				c = min(max(s[prefix+i], ' '), '~')
+			}
+			n = n*95 + uint64(c-' ')
+		}
+		return n
+	}
+	lo, hi := digits(low), digits(high)
+	if hi <= lo {
+		return low
+	}
+
+	n := lo + r.Uint64N(hi-lo+1)
+	suffix := make([]byte, textPivotLength)
+	for i := textPivotLength - 1; i >= 0; i-- {
+		suffix[i] = byte(n%95) + ' '
+		n /= 95
+	}
+
+	return low[:prefix] + strings.TrimRight(string(suffix), " ")
+}
@@ -0,0 +1,87 @@
+package dataloader
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// minLatency is the upper bound of the first latency bucket.
+	minLatency = time.Microsecond
+	// latencyGrowth is the ratio between the bounds of two consecutive buckets, and the percentiles precision.
+	latencyGrowth = 1.05
+	// latencyBuckets covers from 1µs up to about 13 minutes.
+	latencyBuckets = 420
+)
+
+// LatencyHistogram records latencies in logarithmic buckets, using constant memory for any number of samples.
+// It is safe for concurrent use.
+type LatencyHistogram struct {
+	mu     sync.Mutex
+	counts [latencyBuckets]int64
+	count  int64
+	sum    time.Duration
+	max    time.Duration
+}
+
+// LatencySummary is a snapshot of a latency histogram.
+type LatencySummary struct {
+	Count int64
+	Mean  time.Duration
+	P50   time.Duration
+	P95   time.Duration
+	P99   time.Duration
+	Max   time.Duration
+}
+
+// Record adds a latency to the histogram.
+func (h *LatencyHistogram) Record(latency time.Duration) {
+	bucket := 0
+	if latency > minLatency {
+		bucket = int(math.Ceil(math.Log(float64(latency)/float64(minLatency)) / math.Log(latencyGrowth)))
+		bucket = min(bucket, latencyBuckets-1)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.counts[bucket]++
+	h.count++
+	h.sum += latency
+	h.max = max(h.max, latency)
+}
+
+// Summary returns the number of samples, the mean, the main percentiles and the maximum latency.
+func (h *LatencyHistogram) Summary() LatencySummary {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.count == 0 {
+		return LatencySummary{}
+	}
+
+	return LatencySummary{
+		Count: h.count,
+		Mean:  h.sum / time.Duration(h.count),
+		P50:   h.percentile(0.50),
+		P95:   h.percentile(0.95),
+		P99:   h.percentile(0.99),
+		Max:   h.max,
+	}
+}
+
+// percentile returns the upper bound of the bucket holding the percentile, capped by the maximum latency.
+func (h *LatencyHistogram) percentile(p float64) time.Duration {
+	rank := int64(math.Ceil(p * float64(h.count)))
+	seen := int64(0)
+	for bucket, count := range h.counts {
+		seen += count
+		if seen >= rank {
+			bound := time.Duration(float64(minLatency) * math.Pow(latencyGrowth, float64(bucket)))
+			return min(bound, h.max)
+		}
+	}
+
+	return h.max
+}
@@ -0,0 +1,27 @@
+package dataloader_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/dataloader"
+)
+
+func TestLatencyHistogram(t *testing.T) {
+	var histogram dataloader.LatencyHistogram
+	assert.Equal(t, dataloader.LatencySummary{}, histogram.Summary())
+
+	for i := 1; i <= 100; i++ {
+		histogram.Record(time.Duration(i) * time.Millisecond)
+	}
+
+	summary := histogram.Summary()
+	assert.Equal(t, int64(100), summary.Count)
+	assert.Equal(t, 50500*time.Microsecond, summary.Mean)
+	assert.InEpsilon(t, float64(50*time.Millisecond), float64(summary.P50), 0.05)
+	assert.InEpsilon(t, float64(95*time.Millisecond), float64(summary.P95), 0.05)
+	assert.InEpsilon(t, float64(99*time.Millisecond), float64(summary.P99), 0.05)
+	assert.Equal(t, 100*time.Millisecond, summary.Max)
+}
@@ -66,3 +66,35 @@ func TestMySQLDataLoader(t *testing.T) {
 	//err = cleanup(db)
 	//assert.NoError(t, err)
 }
+
+func TestMySQLCleanTable(t *testing.T) {
+	ctx := t.Context()
+
+	db, err := connectMySQL(ctx, host, myPort, myUser, myPass, "testdb")
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, cleanup(db))
+	require.NoError(t, createTestTable(db, "mysql"))
+
+	tableStruct, err := mysqlparser.Parse(db, "testdb", "test_table")
+	require.NoError(t, err)
+
+	loader := dataloader.NewTableDataLoader(db, "mysql", tableStruct, batchSize, 1)
+	require.NoError(t, loader.SetDefaultGenerators())
+	require.NoError(t, loader.LoadData(ctx, 100, batchSize))
+
+	deleted, err := loader.DeleteWhere(ctx, "id <= 10")
+	require.NoError(t, err)
+	assert.EqualValues(t, 10, deleted)
+	count, err := countRows(db)
+	require.NoError(t, err)
+	assert.Equal(t, 90, count)
+
+	require.NoError(t, loader.Truncate(ctx))
+	count, err = countRows(db)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	assert.NoError(t, cleanup(db))
+}
@@ -0,0 +1,439 @@
+package dataloader
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+)
+
+// Partition key units: the range bounds are converted to integers, days or seconds since the epoch.
+const (
+	unitInteger = iota
+	unitDay
+	unitSecond
+)
+
+// toDaysEpoch is the value of the MySQL TO_DAYS function on 1970-01-01.
+const toDaysEpoch = 719528
+
+// ErrUnconstrainedPartitionKey is returned when the partition key values cannot be constrained to the partitions
+// without weights, in which case the key keeps its generator.
+var ErrUnconstrainedPartitionKey = errors.New("the partition key values are not constrained to the partitions")
+
+// partitionKeyExpression matches the supported partition keys: a column, or a MySQL function on a column.
+var partitionKeyExpression = regexp.MustCompile(`^(?:(year|to_days|unix_timestamp)\((\w+)\)|(\w+))$`)
+
+// PartitionGenerator generates the values of a partition key, choosing the partition of every value by
+// weight and drawing the value within its bounds.
+type PartitionGenerator struct {
+	Partitions []string
+	Weights    []int
+
+	total      int
+	generators []DataGenerator
+}
+
+// GenerateValue generates a key value of a random partition.
+func (g *PartitionGenerator) GenerateValue(r *rand.Rand) interface{} {
+	n := r.IntN(g.total)
+	for i, weight := range g.Weights {
+		if n < weight {
+			return g.generators[i].GenerateValue(r)
+		}
+		n -= weight
+	}
+
+	return nil
+}
+
+// rangeGenerator generates the values of a range partition, from From, inclusive, to To, exclusive, in the
+// unit of the key.
+type rangeGenerator struct {
+	From, To int64
+	value    func(int64) any
+}
+
+func (g *rangeGenerator) GenerateValue(r *rand.Rand) interface{} {
+	return g.value(g.From + r.Int64N(g.To-g.From))
+}
+
+// listGenerator generates the values of a list partition.
+type listGenerator struct {
+	Values []*string
+}
+
+func (g *listGenerator) GenerateValue(r *rand.Rand) interface{} {
+	if value := g.Values[r.IntN(len(g.Values))]; value != nil {
+		return *value
+	}
+
+	return nil
+}
+
+// ParsePartitionWeights parses the weights of the partitions, like p2024:3,p2025:1.
+func ParsePartitionWeights(spec string) (map[string]int, error) {
+	weights := make(map[string]int)
+	for _, part := range strings.Split(spec, ",") {
+		name, weight, found := strings.Cut(strings.TrimSpace(part), ":")
+		value, err := strconv.Atoi(weight)
+		if !found || name == "" || err != nil || value < 0 {
+			return nil, fmt.Errorf("invalid partition weight %q, expected partition:weight", part)
+		}
+		weights[name] = value
+	}
+
+	return weights, nil
+}
+
+// SetPartitionGenerator replaces the generator of the partition key so the values fall within the partitions,
+// distributed by the weights of their names. Without weights the rows are distributed evenly between the
+// partitions but the default one, which gets the values of the previous generator. The keys of hash
+// partitions, and the keys that are not generated, like auto-increment columns, are left as they are.
+// Without weights, a key that cannot be constrained keeps its generator and ErrUnconstrainedPartitionKey is
+// returned, so the caller decides whether to report it.
+func (l *TableDataLoader) SetPartitionGenerator(weights map[string]int) error {
+	partitioning := l.TableStruct.Partitioning
+	if partitioning == nil {
+		if weights != nil {
+			return fmt.Errorf("table %s is not partitioned", l.TableStruct.Name)
+		}
+		return nil
+	}
+
+	for name := range weights {
+		if !slices.ContainsFunc(partitioning.Partitions, func(p domain.Partition) bool { return p.Name == name }) {
+			return fmt.Errorf("table %s has no partition %s", l.TableStruct.Name, name)
+		}
+	}
+
+	generator, err := l.partitionGenerator(weights)
+	if err != nil {
+		if weights != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %w", ErrUnconstrainedPartitionKey, err)
+	}
+	if generator == nil {
+		return nil
+	}
+
+	column, _ := l.partitionKeyColumn()
+	l.Generators[column.Name] = generator
+
+	return nil
+}
+
+// partitionGenerator returns the generator of the partition key, nil if it is left as it is.
+func (l *TableDataLoader) partitionGenerator(weights map[string]int) (*PartitionGenerator, error) {
+	partitioning := l.TableStruct.Partitioning
+	if partitioning.Method == domain.PartitionHash {
+		if weights != nil {
+			return nil, errors.New("the rows cannot be distributed between hash partitions")
+		}
+		return nil, nil //nolint:nilnil // Hash partitions take any value.
+	}
+
+	column, function := l.partitionKeyColumn()
+	if column == nil {
+		return nil, fmt.Errorf("unsupported partition key %s", partitioning.Expression)
+	}
+	previous, ok := l.Generators[column.Name]
+	if !ok {
+		return nil, nil //nolint:nilnil // Keys filled by the database are left to it.
+	}
+
+	var ranges *partitionRanges
+	if partitioning.Method == domain.PartitionRange {
+		var err error
+		if ranges, err = newPartitionRanges(*column, function, partitioning.Partitions); err != nil {
+			return nil, err
+		}
+	}
+
+	g := &PartitionGenerator{}
+	for i, partition := range partitioning.Partitions {
+		weight := 1
+		if partition.IsDefault {
+			weight = 0
+		}
+		if weights != nil {
+			weight = weights[partition.Name]
+		}
+
+		var generator DataGenerator
+		switch {
+		case partition.IsDefault:
+			generator = previous
+		case partitioning.Method == domain.PartitionList:
+			if len(partition.Values) == 0 {
+				weight = 0
+			}
+			generator = &listGenerator{Values: partition.Values}
+		default:
+			generator = ranges.generator(i)
+		}
+
+		g.Partitions = append(g.Partitions, partition.Name)
+		g.Weights = append(g.Weights, weight)
+		g.generators = append(g.generators, generator)
+		g.total += weight
+	}
+	if g.total == 0 {
+		return nil, errors.New("no partition has a weight")
+	}
+
+	return g, nil
+}
+
+// partitionKeyColumn returns the column of the partition key and the MySQL function applied to it, if any.
+// The column is nil if the key is not supported, like keys of multiple columns.
+func (l *TableDataLoader) partitionKeyColumn() (*domain.TableColumn, string) {
+	expression := strings.NewReplacer("`", "", `"`, "", " ", "").Replace(l.TableStruct.Partitioning.Expression)
+	match := partitionKeyExpression.FindStringSubmatch(strings.ToLower(expression))
+	if match == nil {
+		return nil, ""
+	}
+
+	name := match[2] + match[3]
+	for i, column := range l.TableStruct.Columns {
+		if strings.EqualFold(column.Name, name) {
+			return &l.TableStruct.Columns[i], match[1]
+		}
+	}
+
+	return nil, ""
+}
+
+// partitionRanges are the bounds of range partitions in the unit of the key.
+type partitionRanges struct {
+	from, to  []*int64 // Nil if unbounded
+	span      int64    // Width of the unbounded partitions
+	low, high int64    // Values of the key type, from low, inclusive, to high, exclusive
+	value     func(int64) any
+}
+
+// newPartitionRanges converts the bounds of the partitions to the unit of the key column.
+func newPartitionRanges(column domain.TableColumn, function string, partitions []domain.Partition,
+) (*partitionRanges, error) {
+	unit, value := keyUnit(column)
+	if unit == unitInteger && function != "" {
+		return nil, fmt.Errorf("unsupported partition key %s(%s) on a %s column", function, column.Name, column.DataType)
+	}
+	if value == nil {
+		return nil, fmt.Errorf("unsupported range partition key type %s", column.DataType)
+	}
+
+	ranges := &partitionRanges{value: value}
+	ranges.low, ranges.high = keyLimits(column)
+	for _, partition := range partitions {
+		from, err := boundUnits(partition.From, unit, function)
+		if err != nil {
+			return nil, fmt.Errorf("partition %s: %w", partition.Name, err)
+		}
+		to, err := boundUnits(partition.To, unit, function)
+		if err != nil {
+			return nil, fmt.Errorf("partition %s: %w", partition.Name, err)
+		}
+		if from != nil && to != nil {
+			ranges.span = max(ranges.span, *to-*from)
+		}
+		ranges.from = append(ranges.from, from)
+		ranges.to = append(ranges.to, to)
+	}
+	if ranges.span == 0 {
+		// No partition is bounded on both ends, the unbounded ones span a thousand values or a year
+		switch unit {
+		case unitDay:
+			ranges.span = 365
+		case unitSecond:
+			ranges.span = 365 * 24 * 3600
+		default:
+			ranges.span = 1000
+		}
+	}
+
+	return ranges, nil
+}
+
+// generator returns the generator of the partition, extending its unbounded ends by the span.
+func (r *partitionRanges) generator(i int) DataGenerator {
+	from, to := r.from[i], r.to[i]
+	switch {
+	case from == nil && to == nil:
+		return r.clamp(0, r.span)
+	case from == nil:
+		return r.clamp(*to-r.span, *to)
+	case to == nil:
+		return r.clamp(*from, *from+r.span)
+	default:
+		return r.clamp(*from, *to)
+	}
+}
+
+// clamp returns the generator of the range limited to the values of the key type, keeping at least one value.
+func (r *partitionRanges) clamp(from, to int64) DataGenerator {
+	from = min(max(from, r.low), r.high-1)
+	to = min(max(to, from+1), r.high)
+
+	return &rangeGenerator{From: from, To: to, value: r.value}
+}
+
+// keyLimits returns the values of the integer types narrower than bigint, from low, inclusive, to high,
+// exclusive, and the whole int64 range for other types.
+func keyLimits(column domain.TableColumn) (int64, int64) {
+	dataType := strings.ToLower(column.DataType)
+	base, _, _ := strings.Cut(strings.Split(dataType, " ")[0], "(")
+	unsigned := strings.Contains(dataType, "unsigned")
+
+	bits := 0
+	switch base {
+	case "tinyint":
+		bits = 8
+	case "smallint":
+		bits = 16
+	case "mediumint":
+		bits = 24
+	case "int", "integer":
+		bits = 32
+	case "bigint":
+		if unsigned {
+			return 0, math.MaxInt64
+		}
+	}
+	switch {
+	case bits == 0:
+		return math.MinInt64, math.MaxInt64
+	case unsigned:
+		return 0, 1 << bits
+	default:
+		return -1 << (bits - 1), 1 << (bits - 1)
+	}
+}
+
+// baseType returns the lower case name of a data type, without length, modifiers or time zone.
+func baseType(dataType string) string {
+	base, _, _ := strings.Cut(strings.TrimSpace(strings.ToLower(dataType)), " ")
+	base, _, _ = strings.Cut(base, "(")
+
+	return base
+}
+
+// isIntegerType reports whether the data type is an integer type.
+func isIntegerType(dataType string) bool {
+	switch baseType(dataType) {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint":
+		return true
+	default:
+		return false
+	}
+}
+
+// isTimestampType reports whether the data type is a date and time type.
+func isTimestampType(dataType string) bool {
+	switch baseType(dataType) {
+	case "timestamp", "datetime", "timestamptz":
+		return true
+	default:
+		return false
+	}
+}
+
+// keyUnit returns the unit of the values of a key column, and the function converting them to values of the
+// column. The function is nil if the column type is not supported.
+func keyUnit(column domain.TableColumn) (int, func(int64) any) {
+	dataType := strings.ToLower(column.DataType)
+	base := baseType(dataType)
+
+	switch {
+	case isIntegerType(base):
+		return unitInteger, func(n int64) any { return n }
+	case base == "date":
+		return unitDay, func(days int64) any { return time.Unix(days*24*3600, 0).UTC().Format(time.DateOnly) }
+	case isTimestampType(base):
+		withTZ := strings.Contains(dataType, "with time zone") || base == "timestamptz"
+		return unitSecond, func(seconds int64) any {
+			if withTZ {
+				return time.Unix(seconds, 0).UTC()
+			}
+			return time.Unix(seconds, 0).UTC().Format(time.DateTime)
+		}
+	default:
+		return unitInteger, nil
+	}
+}
+
+// boundTimeLayouts are the layouts of the date and time bounds, as the catalogs return them.
+var boundTimeLayouts = []string{ //nolint:gochecknoglobals // Constant list.
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z07",
+	"2006-01-02 15:04:05.999999999",
+	time.DateOnly,
+}
+
+// boundUnits converts a partition bound to the unit, after applying the inverse of the MySQL function of the
+// key. It returns nil if the bound is empty.
+func boundUnits(bound string, unit int, function string) (*int64, error) {
+	if bound == "" {
+		return nil, nil //nolint:nilnil // Unbounded.
+	}
+
+	var seconds int64
+	switch function {
+	case "year", "to_days", "unix_timestamp":
+		n, err := strconv.ParseInt(bound, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bound %s: %w", bound, err)
+		}
+		switch function {
+		case "year":
+			seconds = time.Date(int(n), 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+		case "to_days":
+			seconds = (n - toDaysEpoch) * 24 * 3600
+		default:
+			seconds = n
+		}
+	default:
+		if unit == unitInteger {
+			n, err := strconv.ParseInt(bound, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid bound %s: %w", bound, err)
+			}
+			return &n, nil
+		}
+		t, err := parseBoundTime(bound)
+		if err != nil {
+			return nil, err
+		}
+		seconds = t.Unix()
+	}
+
+	if unit == unitDay {
+		// Dates after a bound within a day start on the next day
+		days := seconds / (24 * 3600)
+		if seconds%(24*3600) > 0 {
+			days++
+		}
+		return &days, nil
+	}
+
+	return &seconds, nil
+}
+
+// parseBoundTime parses a date or time bound.
+func parseBoundTime(bound string) (time.Time, error) {
+	for _, layout := range boundTimeLayouts {
+		if t, err := time.Parse(layout, bound); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date or time bound %s", bound)
+}
@@ -0,0 +1,225 @@
+package dataloader_test
+
+import (
+	"errors"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/dataloader"
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+)
+
+func partitionedTable(keyType string, partitioning *domain.Partitioning) *domain.TableStructure {
+	return &domain.TableStructure{
+		Name: "events",
+		Columns: []domain.TableColumn{
+			{Name: "id", DataType: "bigint"},
+			{Name: "k", DataType: keyType},
+		},
+		Indexes: []domain.TableIndex{
+			{Name: "PRIMARY", Columns: []string{"id"}, IsPrimary: true, IsUnique: true},
+		},
+		Partitioning: partitioning,
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
+
+// partitionValues generates the values of the partition key.
+func partitionValues(t *testing.T, table *domain.TableStructure, weights map[string]int) []any {
+	t.Helper()
+
+	loader := dataloader.NewTableDataLoader(nil, "mysql", table, batchSize, parallel)
+	require.NoError(t, loader.SetDefaultGenerators())
+	require.NoError(t, loader.SetPartitionGenerator(weights))
+
+	r := rand.New(rand.NewPCG(1, 2))
+	values := make([]any, 1000)
+	for i := range values {
+		values[i] = loader.Generators["k"].GenerateValue(r)
+	}
+
+	return values
+}
+
+func TestPartitionGeneratorIntegerRanges(t *testing.T) {
+	table := partitionedTable("int", &domain.Partitioning{
+		Method:     domain.PartitionRange,
+		Expression: "`k`",
+		Partitions: []domain.Partition{
+			{Name: "p0", To: "100"},
+			{Name: "p1", From: "100", To: "300"},
+			{Name: "pmax", From: "300"},
+		},
+	})
+
+	counts := map[string]int{}
+	for _, value := range partitionValues(t, table, nil) {
+		k, ok := value.(int64)
+		require.True(t, ok)
+		switch {
+		case k < -100:
+			t.Fatalf("value %d below the extended first partition", k)
+		case k < 100:
+			counts["p0"]++
+		case k < 300:
+			counts["p1"]++
+		case k < 500:
+			counts["pmax"]++
+		default:
+			t.Fatalf("value %d beyond the extended last partition", k)
+		}
+	}
+	for _, name := range []string{"p0", "p1", "pmax"} {
+		assert.InDelta(t, 333, counts[name], 60, name)
+	}
+
+	for _, value := range partitionValues(t, table, map[string]int{"p1": 1}) {
+		assert.GreaterOrEqual(t, value, int64(100))
+		assert.Less(t, value, int64(300))
+	}
+}
+
+func TestPartitionGeneratorTimeRanges(t *testing.T) {
+	// MySQL partitions by year on a date column
+	table := partitionedTable("date", &domain.Partitioning{
+		Method:     domain.PartitionRange,
+		Expression: "year(`k`)",
+		Partitions: []domain.Partition{
+			{Name: "p2023", To: "2024"},
+			{Name: "p2024", From: "2024", To: "2025"},
+		},
+	})
+	for _, value := range partitionValues(t, table, map[string]int{"p2024": 1}) {
+		date, err := time.Parse(time.DateOnly, value.(string))
+		require.NoError(t, err)
+		assert.Equal(t, 2024, date.Year())
+	}
+
+	// Postgres partitions by month on a timestamp column
+	table = partitionedTable("timestamp with time zone", &domain.Partitioning{
+		Method:     domain.PartitionRange,
+		Expression: "k",
+		Partitions: []domain.Partition{
+			{Name: "events_2024_01", From: "2024-01-01 00:00:00+00", To: "2024-02-01 00:00:00+00"},
+			{Name: "events_2024_02", From: "2024-02-01 00:00:00+00", To: "2024-03-01 00:00:00+00"},
+		},
+	})
+	for _, value := range partitionValues(t, table, nil) {
+		ts, ok := value.(time.Time)
+		require.True(t, ok)
+		assert.False(t, ts.Before(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+		assert.True(t, ts.Before(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)))
+	}
+}
+
+func TestPartitionGeneratorLists(t *testing.T) {
+	table := partitionedTable("varchar(10)", &domain.Partitioning{
+		Method:     domain.PartitionList,
+		Expression: "k",
+		Partitions: []domain.Partition{
+			{Name: "eu", Values: []*string{ptr("es"), ptr("fr")}},
+			{Name: "other", Values: []*string{ptr("us"), nil}},
+			{Name: "rest", IsDefault: true},
+		},
+	})
+
+	seen := map[any]int{}
+	for _, value := range partitionValues(t, table, nil) {
+		seen[value]++
+	}
+	assert.Len(t, seen, 4)
+	assert.Contains(t, seen, nil)
+
+	// The default partition gets the values of the previous generator
+	for _, value := range partitionValues(t, table, map[string]int{"rest": 1}) {
+		assert.Len(t, value, 10)
+	}
+}
+
+func TestSetPartitionGeneratorErrors(t *testing.T) {
+	hash := partitionedTable("int", &domain.Partitioning{
+		Method:     domain.PartitionHash,
+		Expression: "k",
+		Partitions: []domain.Partition{{Name: "p0"}, {Name: "p1"}},
+	})
+	expression := partitionedTable("int", &domain.Partitioning{
+		Method:     domain.PartitionRange,
+		Expression: "k % 10",
+		Partitions: []domain.Partition{{Name: "p0", To: "5"}},
+	})
+	ranges := partitionedTable("int", &domain.Partitioning{
+		Method:     domain.PartitionRange,
+		Expression: "k",
+		Partitions: []domain.Partition{{Name: "p0", To: "5"}, {Name: "p1", From: "5"}},
+	})
+
+	for name, test := range map[string]struct {
+		table   *domain.TableStructure
+		weights map[string]int
+		err     string
+	}{
+		"not partitioned":    {partitionedTable("int", nil), map[string]int{"p0": 1}, "not partitioned"},
+		"unknown partition":  {hash, map[string]int{"p2": 1}, "no partition p2"},
+		"hash weights":       {hash, map[string]int{"p0": 1}, "hash partitions"},
+		"unsupported key":    {expression, map[string]int{"p0": 1}, "unsupported partition key"},
+		"no weight":          {ranges, map[string]int{"p0": 0}, "no partition has a weight"},
+		"hash without error": {hash, nil, ""},
+		"unsupported, kept":  {expression, nil, "not constrained"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			loader := dataloader.NewTableDataLoader(nil, "mysql", test.table, batchSize, parallel)
+			require.NoError(t, loader.SetDefaultGenerators())
+			err := loader.SetPartitionGenerator(test.weights)
+			if test.err == "" || test.weights == nil {
+				assert.IsType(t, &dataloader.IntGenerator{}, loader.Generators["k"])
+			}
+			if test.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, test.err)
+			assert.Equal(t, test.weights == nil, errors.Is(err, dataloader.ErrUnconstrainedPartitionKey))
+		})
+	}
+}
+
+func TestPartitionGeneratorTypeLimits(t *testing.T) {
+	// The unbounded partitions would extend to -100 and 300, beyond the tinyint unsigned values
+	table := partitionedTable("tinyint unsigned", &domain.Partitioning{
+		Method:     domain.PartitionRange,
+		Expression: "k",
+		Partitions: []domain.Partition{
+			{Name: "p0", To: "100"},
+			{Name: "p1", From: "100", To: "200"},
+			{Name: "pmax", From: "200"},
+		},
+	})
+
+	counts := map[string]int{}
+	for _, value := range partitionValues(t, table, nil) {
+		k, ok := value.(int64)
+		require.True(t, ok)
+		switch {
+		case k < 0:
+			t.Fatalf("value %d below the type", k)
+		case k < 100:
+			counts["p0"]++
+		case k < 200:
+			counts["p1"]++
+		case k < 256:
+			counts["pmax"]++
+		default:
+			t.Fatalf("value %d beyond the type", k)
+		}
+	}
+	for _, name := range []string{"p0", "p1", "pmax"} {
+		assert.InDelta(t, 333, counts[name], 60, name)
+	}
+}
@@ -0,0 +1,109 @@
+package dataloader
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// maxParamLength is the maximum length of a generator parameter value in a column plan.
+const maxParamLength = 40
+
+// ColumnPlan describes how the values of a column are going to be generated.
+type ColumnPlan struct {
+	Column     string
+	DataType   string
+	Generator  string  // Generator type, empty if the column is skipped
+	Params     string  // Generator parameters as name=value pairs
+	NullRatio  float64 // Fraction of NULL values
+	SkipReason string  // Why the column has no generator
+}
+
+// SetNullRatio makes the generators of all the nullable columns return NULL with the given ratio.
+func (l *TableDataLoader) SetNullRatio(ratio float64) {
+	if ratio <= 0 {
+		return
+	}
+
+	for _, column := range l.TableStruct.Columns {
+		generator, ok := l.Generators[column.Name]
+		if !ok || !column.Nullable {
+			continue
+		}
+		if nullable, isNullable := generator.(*NullableGenerator); isNullable {
+			nullable.Ratio = ratio
+			continue
+		}
+		l.Generators[column.Name] = NewNullableGenerator(generator, ratio)
+	}
+}
+
+// Plan returns the generation plan for every column of the table, in table order.
+func (l *TableDataLoader) Plan() []ColumnPlan {
+	plan := make([]ColumnPlan, 0, len(l.TableStruct.Columns))
+
+	for _, column := range l.TableStruct.Columns {
+		columnPlan := ColumnPlan{
+			Column:   column.Name,
+			DataType: column.DataType,
+		}
+
+		generator, ok := l.Generators[column.Name]
+		switch {
+		case ok:
+			if nullable, isNullable := generator.(*NullableGenerator); isNullable {
+				columnPlan.NullRatio = nullable.Ratio
+				generator = nullable.Generator
+			}
+			columnPlan.Generator, columnPlan.Params = describeGenerator(generator, maxParamLength)
+		case l.isPrimaryAutoIncrement(column):
+			columnPlan.SkipReason = "auto-increment primary key"
+		case l.isForeignKey(column):
+			columnPlan.SkipReason = "foreign key of unsupported type"
+		default:
+			columnPlan.SkipReason = "no generator for data type"
+		}
+
+		plan = append(plan, columnPlan)
+	}
+
+	return plan
+}
+
+// SampleRows generates numRows rows without loading them. Values follow the order of the generated columns.
+// The rows are the first ones a load with the same seed would generate.
+func (l *TableDataLoader) SampleRows(numRows int) ([]string, [][]any) {
+	generator := l.newRowGenerator()
+	names := make([]string, len(generator.columns))
+	for i, column := range generator.columns {
+		names[i] = column.Name
+	}
+
+	return names, l.generateBatch(generator, 0, numRows, l.rowOffset)
+}
+
+// describeGenerator returns the generator type name and its exported fields as name=value pairs.
+// Values longer than maxLength are truncated, unless maxLength is 0.
+func describeGenerator(generator DataGenerator, maxLength int) (string, string) {
+	value := reflect.Indirect(reflect.ValueOf(generator))
+	name := value.Type().Name()
+	if value.Kind() != reflect.Struct {
+		return name, ""
+	}
+
+	params := make([]string, 0, value.NumField())
+	for i := range value.NumField() {
+		field := value.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		param := fmt.Sprintf("%v", value.Field(i).Interface())
+		if maxLength > 0 && len(param) > maxLength {
+			param = param[:maxLength-3] + "..."
+		}
+		params = append(params, field.Name+"="+param)
+	}
+
+	return name, strings.Join(params, " ")
+}
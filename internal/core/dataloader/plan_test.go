@@ -0,0 +1,100 @@
+package dataloader_test
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/dataloader"
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+	"github.com/cfsalguero/random_data_loader/internal/core/metrics"
+)
+
+func planTable() *domain.TableStructure {
+	return &domain.TableStructure{
+		Name: "test_table",
+		Columns: []domain.TableColumn{
+			{Name: "id", DataType: "int"},
+			{Name: "name", DataType: "varchar", Nullable: true},
+			{Name: "price", DataType: "decimal", Precision: 5, Scale: 2},
+		},
+		Indexes: []domain.TableIndex{{Name: "PRIMARY", Columns: []string{"id"}, IsPrimary: true, IsUnique: true}},
+	}
+}
+
+func TestPlan(t *testing.T) {
+	loader := dataloader.NewTableDataLoader(nil, "postgres", planTable(), batchSize, parallel)
+	require.NoError(t, loader.SetDefaultGenerators())
+	loader.SetNullRatio(0.25)
+
+	plan := loader.Plan()
+	require.Len(t, plan, 3)
+
+	assert.Equal(t, "auto-increment primary key", plan[0].SkipReason)
+	assert.Empty(t, plan[0].Generator)
+
+	assert.Equal(t, "StringGenerator", plan[1].Generator)
+	assert.InDelta(t, 0.25, plan[1].NullRatio, 0)
+	assert.Contains(t, plan[1].Params, "Length=10")
+
+	assert.Equal(t, "FloatGenerator", plan[2].Generator)
+	assert.Equal(t, "Min=-999 Max=999 Prec=2", plan[2].Params)
+	assert.Zero(t, plan[2].NullRatio)
+
+	assert.Equal(t, "INSERT INTO test_table (name, price) VALUES ($1, $2)", loader.InsertQuery())
+}
+
+func TestSampleRows(t *testing.T) {
+	loader := dataloader.NewTableDataLoader(nil, "mysql", planTable(), batchSize, parallel)
+	require.NoError(t, loader.SetDefaultGenerators())
+
+	names, rows := loader.SampleRows(3)
+	assert.Equal(t, []string{"name", "price"}, names)
+	require.Len(t, rows, 3)
+	for _, row := range rows {
+		require.Len(t, row, 2)
+		assert.Len(t, row[0], 10)
+		assert.InDelta(t, 0, row[1], 999)
+	}
+}
+
+func TestSampleRowsMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	loader := dataloader.NewTableDataLoader(nil, "mysql", planTable(), batchSize, parallel)
+	require.NoError(t, loader.SetDefaultGenerators())
+	loader.Metrics = metrics.New(reg)
+
+	_, rows := loader.SampleRows(5)
+	require.Len(t, rows, 5)
+
+	// Every row is counted, the generators are only timed for the first row of the batch
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	counts := map[string]uint64{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			switch family.GetName() {
+			case "random_data_loader_rows_generated_total":
+				assert.InDelta(t, 5, metric.GetCounter().GetValue(), 0)
+			case "random_data_loader_generator_duration_seconds":
+				counts[metric.GetLabel()[0].GetValue()] = metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	assert.Equal(t, map[string]uint64{"name": 1, "price": 1}, counts)
+}
+
+func TestFractionalDecimal(t *testing.T) {
+	table := &domain.TableStructure{
+		Name:    "rates",
+		Columns: []domain.TableColumn{{Name: "rate", DataType: "numeric(3,3)", Precision: 3, Scale: 3}},
+	}
+	loader := dataloader.NewTableDataLoader(nil, "postgres", table, batchSize, parallel)
+	require.NoError(t, loader.SetDefaultGenerators())
+
+	plan := loader.Plan()
+	require.Len(t, plan, 1)
+	assert.Equal(t, "Min=-0.999 Max=0.999 Prec=3", plan[0].Params)
+}
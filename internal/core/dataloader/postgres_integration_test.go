@@ -82,3 +82,35 @@ func TestPostgresDataLoader(t *testing.T) {
 	err = cleanup(db)
 	assert.NoError(t, err)
 }
+
+func TestPostgresCleanTable(t *testing.T) {
+	ctx := t.Context()
+
+	db, err := connectPostgres(ctx, host, pgPort, pgUser, pgPass, database)
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, cleanup(db))
+	require.NoError(t, createTestTable(db, "postgres"))
+
+	tableStruct, err := postgresparser.Parse(db, "testdb", "test_table")
+	require.NoError(t, err)
+
+	loader := dataloader.NewTableDataLoader(db, "postgres", tableStruct, batchSize, 1)
+	require.NoError(t, loader.SetDefaultGenerators())
+	require.NoError(t, loader.LoadData(ctx, 100, batchSize))
+
+	deleted, err := loader.DeleteWhere(ctx, "id <= 10")
+	require.NoError(t, err)
+	assert.EqualValues(t, 10, deleted)
+	count, err := countRows(db)
+	require.NoError(t, err)
+	assert.Equal(t, 90, count)
+
+	require.NoError(t, loader.Truncate(ctx))
+	count, err = countRows(db)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	assert.NoError(t, cleanup(db))
+}
@@ -0,0 +1,107 @@
+package dataloader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// rateSmoothing is the weight of the last interval in the moving average of the rate.
+	rateSmoothing = 0.3
+	barWidth      = 30
+)
+
+// ProgressReporter periodically reports the progress of a load.
+type ProgressReporter struct {
+	Interval time.Duration
+	// Bar, when set, receives a single line progress bar instead of structured log events.
+	Bar io.Writer
+
+	rate float64 // Moving average of the rows per second
+}
+
+// NewProgressReporter creates a new progress reporter. If bar is nil the progress is logged.
+func NewProgressReporter(interval time.Duration, bar io.Writer) *ProgressReporter {
+	return &ProgressReporter{
+		Interval: interval,
+		Bar:      bar,
+	}
+}
+
+// Run reports the progress of the load every interval until the context is done.
+func (p *ProgressReporter) Run(ctx context.Context, stats *LoadStats, total int64) {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	last := stats.Summary()
+	for {
+		select {
+		case <-ctx.Done():
+			if p.Bar != nil {
+				p.report(stats.Summary(), total)
+				_, _ = fmt.Fprintln(p.Bar)
+			}
+			return
+		case <-ticker.C:
+			current := stats.Summary()
+			p.updateRate(last, current)
+			p.report(current, total)
+			last = current
+		}
+	}
+}
+
+func (p *ProgressReporter) updateRate(last, current Summary) {
+	seconds := (current.Elapsed - last.Elapsed).Seconds()
+	if seconds <= 0 {
+		return
+	}
+
+	rate := float64(current.RowsCommitted-last.RowsCommitted) / seconds
+	if p.rate == 0 {
+		p.rate = rate
+		return
+	}
+	p.rate = rateSmoothing*rate + (1-rateSmoothing)*p.rate
+}
+
+// eta estimates the remaining time from the moving average of the rate.
+func (p *ProgressReporter) eta(current Summary, total int64) time.Duration {
+	if p.rate <= 0 || total <= current.RowsCommitted {
+		return 0
+	}
+
+	return time.Duration(float64(total-current.RowsCommitted) / p.rate * float64(time.Second)).Round(time.Second)
+}
+
+func (p *ProgressReporter) report(current Summary, total int64) {
+	eta := p.eta(current, total)
+
+	if p.Bar == nil {
+		log.Info().
+			Int64("rows", current.RowsCommitted).
+			Int64("total", total).
+			Float64("rows_per_sec", p.rate).
+			Dur("eta", eta).
+			Int64("errors", current.Errors).
+			Int64("retries", current.Retries).
+			Msg("Load progress")
+		return
+	}
+
+	done := 0
+	percent := 0.0
+	if total > 0 {
+		percent = min(float64(current.RowsCommitted)/float64(total), 1)
+		done = int(percent * barWidth)
+	}
+
+	_, _ = fmt.Fprintf(p.Bar, "\r[%s%s] %5.1f%% %d/%d rows  %.0f rows/s  ETA %v  errors %d  retries %d ",
+		strings.Repeat("#", done), strings.Repeat(".", barWidth-done), percent*100,
+		current.RowsCommitted, total, p.rate, eta, current.Errors, current.Retries)
+}
@@ -0,0 +1,40 @@
+package dataloader_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/dataloader"
+)
+
+func TestLoadStatsSummary(t *testing.T) {
+	stats := dataloader.NewLoadStats(2)
+	stats.Workers[0].RowsCommitted.Add(10)
+	stats.Workers[1].RowsCommitted.Add(5)
+	stats.Workers[1].Errors.Add(1)
+	stats.Workers[0].Retries.Add(2)
+
+	summary := stats.Summary()
+	assert.Equal(t, int64(15), summary.RowsCommitted)
+	assert.Equal(t, int64(1), summary.Errors)
+	assert.Equal(t, int64(2), summary.Retries)
+	assert.Positive(t, summary.Rate())
+}
+
+func TestProgressReporterBar(t *testing.T) {
+	stats := dataloader.NewLoadStats(1)
+	stats.Workers[0].RowsCommitted.Add(50)
+
+	var bar bytes.Buffer
+	reporter := dataloader.NewProgressReporter(10*time.Millisecond, &bar)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 35*time.Millisecond)
+	defer cancel()
+	reporter.Run(ctx, stats, 100)
+
+	assert.Contains(t, bar.String(), " 50.0% 50/100 rows")
+}
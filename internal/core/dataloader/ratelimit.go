@@ -0,0 +1,112 @@
+package dataloader
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// maxRateWait is the longest a worker sleeps before checking the rate schedule again.
+const maxRateWait = 100 * time.Millisecond
+
+// RateSchedule defines the insert rate over time.
+type RateSchedule struct {
+	Rate        float64       // Rows per second
+	RampUp      time.Duration // Time to increase the rate linearly from 0 to Rate
+	BurstRate   float64       // Rows per second during bursts
+	BurstEvery  time.Duration // Interval between the start of two bursts
+	BurstLength time.Duration // Duration of every burst
+}
+
+// At returns the rate the given time after the start of the load.
+func (s RateSchedule) At(elapsed time.Duration) float64 {
+	if s.BurstEvery > 0 && s.BurstLength > 0 && elapsed >= s.BurstEvery && elapsed%s.BurstEvery < s.BurstLength {
+		return s.BurstRate
+	}
+	if elapsed < s.RampUp {
+		return s.Rate * elapsed.Seconds() / s.RampUp.Seconds()
+	}
+
+	return s.Rate
+}
+
+// RateLimiter is a token bucket, in rows, shared by all the workers of a load.
+// A worker takes the tokens of a whole batch at once, going into debt if needed, and the next
+// workers wait until the debt is paid.
+type RateLimiter struct {
+	Schedule RateSchedule
+	Burst    float64 // Bucket capacity, the rows that can be inserted at once after an idle period
+
+	mu     sync.Mutex
+	tokens float64
+	start  time.Time
+	last   time.Time
+}
+
+// NewRateLimiter creates a rate limiter following the schedule. The schedule starts with the first Wait, so the
+// time spent preparing the load does not count towards the ramp-up.
+func NewRateLimiter(schedule RateSchedule, burst int) *RateLimiter {
+	return &RateLimiter{
+		Schedule: schedule,
+		Burst:    float64(burst),
+	}
+}
+
+// MinRate returns the lowest positive rate of the schedule, 0 if it has none.
+func (s RateSchedule) MinRate() float64 {
+	switch {
+	case s.Rate > 0 && s.BurstRate > 0:
+		return min(s.Rate, s.BurstRate)
+	case s.Rate > 0:
+		return s.Rate
+	default:
+		return max(s.BurstRate, 0)
+	}
+}
+
+// Wait blocks until n rows can be inserted or the context is done. A nil limiter never blocks.
+func (r *RateLimiter) Wait(ctx context.Context, n int) error {
+	if r == nil {
+		return nil
+	}
+
+	for {
+		wait := r.reserve(n)
+		if wait == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve refills the bucket and takes n tokens if it is not in debt.
+// Otherwise, it returns how long to wait before trying again.
+func (r *RateLimiter) reserve(n int) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if r.start.IsZero() {
+		r.start, r.last = now, now
+	}
+	rate := r.Schedule.At(now.Sub(r.start))
+	r.tokens = min(r.tokens+rate*now.Sub(r.last).Seconds(), r.Burst)
+	r.last = now
+
+	if r.tokens >= 0 {
+		r.tokens -= float64(n)
+		return 0
+	}
+	if rate <= 0 {
+		return maxRateWait
+	}
+
+	return min(time.Duration(-r.tokens/rate*float64(time.Second))+time.Millisecond, maxRateWait)
+}
@@ -0,0 +1,63 @@
+package dataloader_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/dataloader"
+)
+
+func TestRateScheduleAt(t *testing.T) {
+	schedule := dataloader.RateSchedule{
+		Rate:        100,
+		RampUp:      10 * time.Second,
+		BurstRate:   500,
+		BurstEvery:  time.Minute,
+		BurstLength: 5 * time.Second,
+	}
+
+	assert.InDelta(t, 0, schedule.At(0), 0.001)
+	assert.InDelta(t, 50, schedule.At(5*time.Second), 0.001)
+	assert.InDelta(t, 100, schedule.At(30*time.Second), 0.001)
+	assert.InDelta(t, 500, schedule.At(62*time.Second), 0.001)
+	assert.InDelta(t, 100, schedule.At(66*time.Second), 0.001)
+
+	assert.InDelta(t, 100, schedule.MinRate(), 0.001)
+	assert.InDelta(t, 500, dataloader.RateSchedule{BurstRate: 500}.MinRate(), 0.001)
+	assert.InDelta(t, 0, dataloader.RateSchedule{}.MinRate(), 0.001)
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := dataloader.NewRateLimiter(dataloader.RateSchedule{Rate: 1000}, 10)
+	start := time.Now()
+
+	// 4 workers taking 12 batches of 5 rows: 240 rows at 1000 rows/s
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 12 {
+				assert.NoError(t, limiter.Wait(context.Background(), 5))
+			}
+		}()
+	}
+	wg.Wait()
+
+	elapsed := time.Since(start)
+	assert.Greater(t, elapsed, 200*time.Millisecond)
+	assert.Less(t, elapsed, time.Second)
+
+	// The first batch goes into debt, the next one waits until it is canceled
+	limiter = dataloader.NewRateLimiter(dataloader.RateSchedule{Rate: 1}, 0)
+	require.NoError(t, limiter.Wait(context.Background(), 10))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, limiter.Wait(ctx, 1), context.Canceled)
+}
@@ -0,0 +1,240 @@
+package dataloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// rangeScanRows is the number of rows read by range scans and joins.
+const rangeScanRows = 100
+
+// Sources of the predicate values of read queries.
+const (
+	ValuesLive      = "live"      // Values sampled from the table
+	ValuesGenerated = "generated" // Values from the column generators
+)
+
+// QueryShape is a parameterized read query derived from the table indexes or foreign keys.
+type QueryShape struct {
+	Name    string   // Kind and index or foreign key, e.g. point:PRIMARY
+	Query   string   // Query with a placeholder for every predicate column
+	Columns []string // Columns of the table the predicate values are drawn for
+}
+
+// ReadOptions configures a read workload.
+type ReadOptions struct {
+	Values     string        // Source of the predicate values, ValuesLive or ValuesGenerated
+	Operations int           // Total number of queries, ignored if Duration is set
+	Duration   time.Duration // Run the workload for this long
+}
+
+// ReadShapes returns the read queries the table supports: point lookups by primary and unique keys,
+// range scans on indexes starting with an integer, date or time column, and joins to the tables
+// referenced by foreign keys.
+func (l *TableDataLoader) ReadShapes() []QueryShape {
+	table := l.TableStruct.Name
+	var shapes []QueryShape
+
+	for _, index := range l.TableStruct.Indexes {
+		if index.IsPrimary || index.IsUnique {
+			shapes = append(shapes, QueryShape{
+				Name:    "point:" + index.Name,
+				Query:   l.rebind(fmt.Sprintf("SELECT * FROM %s WHERE %s", table, equalities(table, index.Columns))),
+				Columns: index.Columns,
+			})
+		}
+	}
+
+	for _, index := range l.TableStruct.Indexes {
+		if len(index.Columns) == 0 {
+			continue
+		}
+		column := index.Columns[0]
+		dataType := l.columnType(column)
+		base := baseType(dataType)
+		if !isIntegerType(dataType) && !isTimestampType(dataType) && base != "date" && base != "time" {
+			continue
+		}
+		shapes = append(shapes, QueryShape{
+			Name: "range:" + index.Name,
+			Query: l.rebind(fmt.Sprintf("SELECT * FROM %[1]s WHERE %[2]s >= ? ORDER BY %[2]s LIMIT %[3]d",
+				table, column, rangeScanRows)),
+			Columns: []string{column},
+		})
+	}
+
+	for _, fk := range l.TableStruct.ForeignKeys {
+		// Both sides are aliased, as self-referencing foreign keys join the table with itself
+		on := make([]string, len(fk.Columns))
+		for i, column := range fk.Columns {
+			on[i] = fmt.Sprintf("t.%s = r.%s", column, fk.ReferencedColumns[i])
+		}
+		shapes = append(shapes, QueryShape{
+			Name: "join:" + fk.Name,
+			Query: l.rebind(fmt.Sprintf("SELECT * FROM %s t JOIN %s r ON %s WHERE %s LIMIT %d",
+				table, fk.ReferencedTable, strings.Join(on, " AND "), equalities("t", fk.Columns), rangeScanRows)),
+			Columns: fk.Columns,
+		})
+	}
+
+	return shapes
+}
+
+// equalities returns the column = ? conditions of the columns of the table or alias, joined with AND.
+func equalities(table string, columns []string) string {
+	conditions := make([]string, len(columns))
+	for i, column := range columns {
+		conditions[i] = table + "." + column + " = ?"
+	}
+
+	return strings.Join(conditions, " AND ")
+}
+
+// readShape is a query shape with the source of its predicate values.
+type readShape struct {
+	QueryShape
+	generators []DataGenerator // Nil when the values are sampled from the table
+	keys       *keySampler
+}
+
+// RunReads runs the read queries of ReadShapes with NumGoroutines workers, picking a random shape for every
+// query. The stats are keyed by shape name; misses are queries returning no rows. Failed queries are only
+// counted, while a worker failing to sample the predicate values stops the reads and its error is returned
+// with the stats so far.
+func (l *TableDataLoader) RunReads(ctx context.Context, options ReadOptions) (WorkloadStats, error) {
+	shapes := l.ReadShapes()
+	if len(shapes) == 0 {
+		return nil, fmt.Errorf("table %s has no indexes or foreign keys to query", l.TableStruct.Name)
+	}
+
+	stats := make(WorkloadStats, len(shapes))
+	reads := make([]*readShape, len(shapes))
+	for i, shape := range shapes {
+		stats[shape.Name] = &OperationStats{}
+		reads[i] = &readShape{QueryShape: shape, keys: l.newKeySampler(shape.Columns)}
+		if options.Values == ValuesGenerated {
+			reads[i].generators = l.columnGenerators(shape.Columns)
+		}
+	}
+
+	if options.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Duration)
+		defer cancel()
+	}
+
+	workersCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var issued atomic.Int64
+	err := l.runWorkers(workersCtx, func(worker int) error {
+		r := rand.New(rand.NewPCG(l.Seed, uint64(worker)))
+		for workersCtx.Err() == nil {
+			if options.Duration == 0 && issued.Add(1) > int64(options.Operations) {
+				return nil
+			}
+			if l.Limiter.Wait(workersCtx, 1) != nil {
+				// Only canceled by the context
+				return nil
+			}
+
+			shape := reads[r.IntN(len(reads))]
+			if err := l.runRead(workersCtx, shape, stats[shape.Name], r); err != nil {
+				if workersCtx.Err() != nil {
+					return nil
+				}
+				// Stop the other workers
+				cancel()
+				return fmt.Errorf("read %s failed: %w", shape.Name, err)
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, workersCtx.Err()) {
+		return stats, err
+	}
+
+	return stats, nil
+}
+
+// columnGenerators returns the generators of the columns, or nil if any column has no generator. The NULL
+// values of nullable generators are left out, as a column = NULL predicate never matches.
+func (l *TableDataLoader) columnGenerators(columns []string) []DataGenerator {
+	generators := make([]DataGenerator, len(columns))
+	for i, column := range columns {
+		generator, ok := l.Generators[column]
+		if !ok {
+			return nil
+		}
+		if nullable, ok := generator.(*NullableGenerator); ok {
+			generator = nullable.Generator
+		}
+		generators[i] = generator
+	}
+
+	return generators
+}
+
+// runRead runs a query of the shape and reads all its rows. Query errors are only counted.
+func (l *TableDataLoader) runRead(ctx context.Context, shape *readShape, stats *OperationStats, r *rand.Rand) error {
+	args := make([]any, len(shape.Columns))
+	if shape.generators != nil {
+		for i, generator := range shape.generators {
+			args[i] = generator.GenerateValue(r)
+		}
+	} else {
+		key, err := shape.keys.Sample(ctx, r)
+		if errors.Is(err, ErrNoKeys) {
+			stats.Misses.Add(1)
+			sleep(ctx, noKeysBackoff)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		args = key
+	}
+
+	start := time.Now()
+	found, err := l.query(ctx, shape.Query, args)
+	if ctx.Err() != nil {
+		// Interrupted by the end of the workload
+		return nil
+	}
+	stats.Latency.Record(time.Since(start))
+	stats.Count.Add(1)
+	if err != nil {
+		stats.Errors.Add(1)
+		l.Metrics.StatementError(sqlState(err))
+		log.Debug().Err(err).Str("shape", shape.Name).Msg("read query failed")
+		return nil
+	}
+	if found == 0 {
+		stats.Misses.Add(1)
+	}
+
+	return nil
+}
+
+// query runs the query and reads all the rows, returning how many there were.
+func (l *TableDataLoader) query(ctx context.Context, query string, args []any) (int, error) {
+	rows, err := l.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	found := 0
+	for rows.Next() {
+		found++
+	}
+
+	return found, rows.Err()
+}
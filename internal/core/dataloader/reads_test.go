@@ -0,0 +1,105 @@
+package dataloader_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/dataloader"
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+)
+
+func TestReadShapes(t *testing.T) {
+	table := &domain.TableStructure{
+		Name: "orders",
+		Columns: []domain.TableColumn{
+			{Name: "id", DataType: "bigint"},
+			{Name: "code", DataType: "varchar(10)"},
+			{Name: "customer_id", DataType: "int"},
+			{Name: "created_at", DataType: "timestamp"},
+		},
+		Indexes: []domain.TableIndex{
+			{Name: "PRIMARY", Columns: []string{"id"}, IsPrimary: true, IsUnique: true},
+			{Name: "code_idx", Columns: []string{"code"}, IsUnique: true},
+			{Name: "created_idx", Columns: []string{"created_at"}},
+		},
+		ForeignKeys: []domain.ForeignKey{
+			{Name: "orders_customer_fk", Columns: []string{"customer_id"}, ReferencedTable: "customers", ReferencedColumns: []string{"id"}},
+		},
+	}
+	loader := dataloader.NewTableDataLoader(nil, "postgres", table, batchSize, parallel)
+
+	shapes := loader.ReadShapes()
+	require.Len(t, shapes, 5)
+
+	assert.Equal(t, dataloader.QueryShape{
+		Name:    "point:PRIMARY",
+		Query:   "SELECT * FROM orders WHERE orders.id = $1",
+		Columns: []string{"id"},
+	}, shapes[0])
+	assert.Equal(t, "point:code_idx", shapes[1].Name)
+	assert.Equal(t, "range:PRIMARY", shapes[2].Name)
+	assert.Equal(t, dataloader.QueryShape{
+		Name:    "range:created_idx",
+		Query:   "SELECT * FROM orders WHERE created_at >= $1 ORDER BY created_at LIMIT 100",
+		Columns: []string{"created_at"},
+	}, shapes[3])
+	assert.Equal(t, dataloader.QueryShape{
+		Name: "join:orders_customer_fk",
+		Query: "SELECT * FROM orders t JOIN customers r ON t.customer_id = r.id " +
+			"WHERE t.customer_id = $1 LIMIT 100",
+		Columns: []string{"customer_id"},
+	}, shapes[4])
+
+	// Self-referencing foreign keys join the table with itself
+	table.ForeignKeys = []domain.ForeignKey{
+		{Name: "orders_parent_fk", Columns: []string{"parent_id"}, ReferencedTable: "orders", ReferencedColumns: []string{"id"}},
+	}
+	shapes = loader.ReadShapes()
+	assert.Equal(t, "SELECT * FROM orders t JOIN orders r ON t.parent_id = r.id WHERE t.parent_id = $1 LIMIT 100",
+		shapes[len(shapes)-1].Query)
+}
+
+func TestReadShapesRangeTypes(t *testing.T) {
+	table := &domain.TableStructure{
+		Name: "places",
+		Columns: []domain.TableColumn{
+			{Name: "location", DataType: "point"},
+			{Name: "duration", DataType: "interval"},
+			{Name: "opened_at", DataType: "timestamp(6) without time zone"},
+			{Name: "visits", DataType: "INT UNSIGNED"},
+		},
+		Indexes: []domain.TableIndex{
+			{Name: "location_idx", Columns: []string{"location"}},
+			{Name: "duration_idx", Columns: []string{"duration"}},
+			{Name: "opened_idx", Columns: []string{"opened_at"}},
+			{Name: "visits_idx", Columns: []string{"visits"}},
+		},
+	}
+	loader := dataloader.NewTableDataLoader(nil, "postgres", table, batchSize, parallel)
+
+	var ranges []string
+	for _, shape := range loader.ReadShapes() {
+		ranges = append(ranges, shape.Name)
+	}
+	assert.Equal(t, []string{"range:opened_idx", "range:visits_idx"}, ranges)
+}
+
+func TestRunReadsWorkerError(t *testing.T) {
+	// Nothing listens on the port, so the predicate values cannot be sampled
+	db, err := sql.Open("mysql", "root:root@tcp(127.0.0.1:1)/testdb")
+	require.NoError(t, err)
+	defer db.Close()
+
+	loader := dataloader.NewTableDataLoader(db, "mysql", planTable(), batchSize, 2)
+	require.NoError(t, loader.SetDefaultGenerators())
+
+	_, err = loader.RunReads(context.Background(), dataloader.ReadOptions{
+		Values:     dataloader.ValuesLive,
+		Operations: 100,
+	})
+	require.ErrorContains(t, err, "cannot sample keys")
+}
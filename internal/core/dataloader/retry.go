@@ -0,0 +1,37 @@
+package dataloader
+
+import (
+	"math/bits"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy defines how many times and how often a failed batch is replayed.
+type RetryPolicy struct {
+	MaxAttempts int // Total attempts per batch, including the first one
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// DefaultRetryPolicy returns the retry policy used by new loaders.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 5,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    10 * time.Second,
+	}
+}
+
+// Backoff returns the delay before the next attempt using exponential backoff with full jitter.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	if p.BaseDelay <= 0 || p.MaxDelay <= 0 {
+		return 0
+	}
+	// The delay saturates at the maximum before the shift overflows
+	ceiling := p.MaxDelay
+	if attempt-1 < bits.Len64(uint64(p.MaxDelay/p.BaseDelay)) {
+		ceiling = p.BaseDelay << (attempt - 1)
+	}
+
+	return time.Duration(rand.Int64N(int64(ceiling) + 1))
+}
@@ -0,0 +1,75 @@
+package dataloader_test
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/dataloader"
+)
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"mysql deadlock", &mysql.MySQLError{Number: 1213}, true},
+		{"mysql lock wait timeout", &mysql.MySQLError{Number: 1205}, true},
+		{"mysql duplicate key", &mysql.MySQLError{Number: 1062}, false},
+		{"mysql invalid connection", mysql.ErrInvalidConn, true},
+		{"postgres serialization failure", &pq.Error{Code: "40001"}, true},
+		{"postgres deadlock", &pq.Error{Code: "40P01"}, true},
+		{"postgres connection failure", &pq.Error{Code: "08006"}, true},
+		{"postgres unique violation", &pq.Error{Code: "23505"}, false},
+		{"bad connection", driver.ErrBadConn, true},
+		{"wrapped", fmt.Errorf("cannot commit transaction: %w", &pq.Error{Code: "40001"}), true},
+		{"other", errors.New("boom"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, dataloader.IsRetryable(tt.err))
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := dataloader.RetryPolicy{MaxAttempts: 5, BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+
+	for attempt := 1; attempt <= 40; attempt++ {
+		ceiling := min(10*time.Millisecond<<min(attempt-1, 10), 50*time.Millisecond)
+		delay := policy.Backoff(attempt)
+		assert.GreaterOrEqual(t, delay, time.Duration(0))
+		assert.LessOrEqual(t, delay, ceiling)
+	}
+}
+
+func TestRetryPolicyBackoffLargeDelays(t *testing.T) {
+	// The shifted base delay would overflow long before the attempt limit
+	policy := dataloader.RetryPolicy{MaxAttempts: 100, BaseDelay: time.Hour, MaxDelay: 24 * time.Hour}
+
+	for attempt := 1; attempt <= 100; attempt++ {
+		delay := policy.Backoff(attempt)
+		assert.GreaterOrEqual(t, delay, time.Duration(0))
+		assert.LessOrEqual(t, delay, min(time.Hour<<min(attempt-1, 5), 24*time.Hour))
+	}
+
+	// Saturated attempts draw up to the maximum delay instead of wrapping around to no delay
+	for attempt := 6; attempt < 64; attempt++ {
+		longest := time.Duration(0)
+		for range 10 {
+			longest = max(longest, policy.Backoff(attempt))
+		}
+		assert.Greater(t, longest, time.Hour, "attempt %d", attempt)
+	}
+
+	assert.Equal(t, time.Duration(0), dataloader.RetryPolicy{BaseDelay: time.Hour}.Backoff(1))
+}
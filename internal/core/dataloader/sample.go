@@ -0,0 +1,65 @@
+package dataloader
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+)
+
+// SampleQuery returns a query selecting about rows random rows of the table matching the SQL condition, if
+// any, without sorting the table: a BERNOULLI TABLESAMPLE on Postgres and a RAND() filter on MySQL. The
+// sampled fraction comes from the row estimate of the table statistics, or from the number of matching rows
+// when there is a condition. A stale estimate samples fewer rows, or favors the first rows scanned.
+func SampleQuery(ctx context.Context, db *sql.DB, dbType, table, columns, condition string, rows int) (string, error) {
+	estimate, err := sampleEstimate(ctx, db, dbType, table, condition)
+	if err != nil {
+		return "", fmt.Errorf("cannot estimate the rows of %s: %w", table, err)
+	}
+	fraction := 1.0
+	if estimate > 0 {
+		fraction = min(float64(rows)/estimate, 1)
+	}
+
+	if dbType == "postgres" {
+		query := fmt.Sprintf("SELECT %s FROM %s TABLESAMPLE BERNOULLI (%s)",
+			columns, table, strconv.FormatFloat(100*fraction, 'f', -1, 64))
+		if condition != "" {
+			query += " WHERE " + condition
+		}
+		return fmt.Sprintf("%s LIMIT %d", query, rows), nil
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE RAND() < %s",
+		columns, table, strconv.FormatFloat(fraction, 'f', -1, 64))
+	if condition != "" {
+		query += " AND (" + condition + ")"
+	}
+
+	return fmt.Sprintf("%s LIMIT %d", query, rows), nil
+}
+
+// sampleEstimate returns the number of rows matching the condition, or the row estimate of the table
+// statistics without condition, 0 if unknown.
+func sampleEstimate(ctx context.Context, db *sql.DB, dbType, table, condition string) (float64, error) {
+	var query string
+	var args []any
+	switch {
+	case condition != "":
+		query = fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", table, condition)
+	case dbType == "postgres":
+		query = "SELECT COALESCE(MAX(reltuples), 0) FROM pg_class WHERE oid = to_regclass($1)"
+		args = []any{table}
+	default:
+		query = `SELECT COALESCE(MAX(TABLE_ROWS), 0) FROM information_schema.TABLES
+			WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?`
+		args = []any{table}
+	}
+
+	var estimate float64
+	if err := db.QueryRowContext(ctx, query, args...).Scan(&estimate); err != nil {
+		return 0, err
+	}
+
+	return estimate, nil
+}
@@ -0,0 +1,248 @@
+package dataloader
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// SessionSetting is a server variable set before inserting and restored after the load. Session settings are set
+// on the connection of every worker, global settings once for the whole server (MySQL only).
+type SessionSetting struct {
+	Name   string
+	Value  string
+	Global bool
+}
+
+// settingName matches the variable names, which cannot be passed as query parameters.
+var settingName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// BulkSessionSettings returns the settings speeding up bulk loads of the database type. MySQL skips the unique
+// and foreign key checks and the binary log, and flushes the redo log once per second instead of on every
+// commit. Postgres commits without waiting for the WAL flush and skips the foreign key and user triggers.
+func BulkSessionSettings(dbType string) []SessionSetting {
+	switch dbType {
+	case "mysql":
+		return []SessionSetting{
+			{Name: "unique_checks", Value: "0"},
+			{Name: "foreign_key_checks", Value: "0"},
+			{Name: "sql_log_bin", Value: "0"},
+			{Name: "innodb_flush_log_at_trx_commit", Value: "2", Global: true},
+		}
+	case "postgres":
+		return []SessionSetting{
+			{Name: "synchronous_commit", Value: "off"},
+			{Name: "session_replication_role", Value: "replica"},
+		}
+	default:
+		return nil
+	}
+}
+
+// ParseSessionSetting parses a name=value setting.
+func ParseSessionSetting(setting string, global bool) (SessionSetting, error) {
+	name, value, ok := strings.Cut(setting, "=")
+	name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+	if !ok || value == "" {
+		return SessionSetting{}, fmt.Errorf("invalid setting %q, expected name=value", setting)
+	}
+	if !settingName.MatchString(name) {
+		return SessionSetting{}, fmt.Errorf("invalid setting name %q", name)
+	}
+
+	return SessionSetting{Name: name, Value: value, Global: global}, nil
+}
+
+// querier runs the queries of the settings on a connection or the whole pool.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// txBeginner starts the transactions of the batches on a connection or the whole pool.
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// setGlobalSettings sets the global settings of the loader and returns the function restoring them.
+// The previous values are logged, to restore them manually if the process is killed.
+func (l *TableDataLoader) setGlobalSettings(ctx context.Context) (func(), error) {
+	var settings []SessionSetting
+	for _, setting := range l.Session {
+		if setting.Global {
+			settings = append(settings, setting)
+		}
+	}
+	if len(settings) == 0 {
+		return func() {}, nil
+	}
+	if l.DBType != "mysql" {
+		return nil, fmt.Errorf("global settings are not supported on %s", l.DBType)
+	}
+
+	previous, err := l.applySettings(ctx, l.DB, settings)
+	if err != nil {
+		return nil, err
+	}
+	for _, setting := range previous {
+		log.Info().Str("previous", setting.Value).Msgf("Set the global %s, restored after the load", setting.Name)
+	}
+
+	return func() {
+		if _, err := l.applySettings(context.WithoutCancel(ctx), l.DB, previous); err != nil {
+			log.Error().Err(err).Msg("Failed to restore the global settings")
+		}
+	}, nil
+}
+
+// applySettings sets the settings and returns their previous values.
+func (l *TableDataLoader) applySettings(ctx context.Context, db querier, settings []SessionSetting,
+) ([]SessionSetting, error) {
+	previous := make([]SessionSetting, 0, len(settings))
+	for _, setting := range settings {
+		if !settingName.MatchString(setting.Name) {
+			return previous, fmt.Errorf("invalid setting name %q", setting.Name)
+		}
+
+		value, err := l.readSetting(ctx, db, setting)
+		if err != nil {
+			return previous, fmt.Errorf("cannot read %s: %w", setting.Name, err)
+		}
+		if err = l.writeSetting(ctx, db, setting); err != nil {
+			return previous, fmt.Errorf("cannot set %s: %w", setting.Name, err)
+		}
+		previous = append(previous, SessionSetting{Name: setting.Name, Value: value, Global: setting.Global})
+	}
+
+	return previous, nil
+}
+
+func (l *TableDataLoader) readSetting(ctx context.Context, db querier, setting SessionSetting) (string, error) {
+	var value sql.NullString
+	var err error
+	switch {
+	case l.DBType == "postgres":
+		err = db.QueryRowContext(ctx, "SELECT current_setting($1)", setting.Name).Scan(&value)
+	case setting.Global:
+		err = db.QueryRowContext(ctx, "SELECT @@GLOBAL."+setting.Name).Scan(&value)
+	default:
+		err = db.QueryRowContext(ctx, "SELECT @@SESSION."+setting.Name).Scan(&value)
+	}
+	if !value.Valid {
+		return "DEFAULT", err
+	}
+
+	return value.String, err
+}
+
+func (l *TableDataLoader) writeSetting(ctx context.Context, db querier, setting SessionSetting) error {
+	var err error
+	switch {
+	case l.DBType == "postgres":
+		_, err = db.ExecContext(ctx, "SELECT set_config($1, $2, false)", setting.Name, setting.Value)
+	case setting.Global:
+		_, err = db.ExecContext(ctx, fmt.Sprintf("SET GLOBAL %s = %s", setting.Name, mysqlSettingValue(setting.Value)))
+	default:
+		_, err = db.ExecContext(ctx, fmt.Sprintf("SET SESSION %s = %s", setting.Name, mysqlSettingValue(setting.Value)))
+	}
+
+	return err
+}
+
+// mysqlSettingValue returns the value as a literal of a SET statement, which does not accept parameters for
+// every variable type.
+func mysqlSettingValue(value string) string {
+	if _, err := strconv.ParseFloat(value, 64); err == nil || strings.EqualFold(value, "DEFAULT") {
+		return value
+	}
+
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// workerSession is the connection a worker inserts its batches on. With session settings the connection is
+// pinned, so the settings apply to every batch, and restored before returning it to the pool.
+type workerSession struct {
+	loader   *TableDataLoader
+	settings []SessionSetting
+	conn     *sql.Conn
+	previous []SessionSetting
+}
+
+// newSession returns the session of a worker, pinning a connection if there are session settings.
+func (l *TableDataLoader) newSession(ctx context.Context) (*workerSession, error) {
+	s := &workerSession{loader: l}
+	for _, setting := range l.Session {
+		if !setting.Global {
+			s.settings = append(s.settings, setting)
+		}
+	}
+
+	return s, s.pin(ctx)
+}
+
+// db returns the pinned connection, or the pool if there are no session settings.
+func (s *workerSession) db() txBeginner {
+	if s.conn == nil {
+		return s.loader.DB
+	}
+
+	return s.conn
+}
+
+// pin takes a connection from the pool and sets the session settings on it, unless there are no settings
+// or it is already pinned.
+func (s *workerSession) pin(ctx context.Context) error {
+	if len(s.settings) == 0 || s.conn != nil {
+		return nil
+	}
+
+	conn, err := s.loader.DB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot get a connection: %w", err)
+	}
+	s.previous, err = s.loader.applySettings(ctx, conn, s.settings)
+	if err != nil {
+		discard(conn)
+		return err
+	}
+	s.conn = conn
+
+	return nil
+}
+
+// drop discards the pinned connection, which may be broken after a failed batch. The next pin takes a new one.
+func (s *workerSession) drop() {
+	if s.conn != nil {
+		discard(s.conn)
+		s.conn = nil
+	}
+}
+
+// close restores the session settings and returns the connection to the pool. A connection whose settings
+// cannot be restored is discarded, so no other query runs with them.
+func (s *workerSession) close(ctx context.Context) {
+	if s.conn == nil {
+		return
+	}
+	if _, err := s.loader.applySettings(context.WithoutCancel(ctx), s.conn, s.previous); err != nil {
+		log.Warn().Err(err).Msg("Failed to restore the session settings, closing the connection")
+		discard(s.conn)
+		return
+	}
+	_ = s.conn.Close()
+}
+
+// discard closes the connection instead of returning it to the pool.
+func discard(conn *sql.Conn) {
+	_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+	if err := conn.Close(); err != nil && !errors.Is(err, sql.ErrConnDone) {
+		log.Debug().Err(err).Msg("Failed to close the connection")
+	}
+}
@@ -0,0 +1,41 @@
+package dataloader_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/dataloader"
+)
+
+func TestParseSessionSetting(t *testing.T) {
+	setting, err := dataloader.ParseSessionSetting(" innodb_flush_log_at_trx_commit = 0", true)
+	require.NoError(t, err)
+	assert.Equal(t, dataloader.SessionSetting{Name: "innodb_flush_log_at_trx_commit", Value: "0", Global: true}, setting)
+
+	setting, err = dataloader.ParseSessionSetting("sql_mode=NO_ENGINE_SUBSTITUTION,STRICT_TRANS_TABLES", false)
+	require.NoError(t, err)
+	assert.Equal(t, "NO_ENGINE_SUBSTITUTION,STRICT_TRANS_TABLES", setting.Value)
+
+	for _, invalid := range []string{"unique_checks", "unique_checks=", "x; DROP TABLE t=1", "=1"} {
+		_, err = dataloader.ParseSessionSetting(invalid, false)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestBulkSessionSettings(t *testing.T) {
+	names := func(settings []dataloader.SessionSetting) []string {
+		var names []string
+		for _, setting := range settings {
+			names = append(names, setting.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"unique_checks", "foreign_key_checks", "sql_log_bin", "innodb_flush_log_at_trx_commit"},
+		names(dataloader.BulkSessionSettings("mysql")))
+	assert.Equal(t, []string{"synchronous_commit", "session_replication_role"},
+		names(dataloader.BulkSessionSettings("postgres")))
+	assert.Empty(t, dataloader.BulkSessionSettings("oracle"))
+}
@@ -1,9 +1,13 @@
 package dataloader
 
 import (
+	"math"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
 )
 
 func (l *TableDataLoader) SetGenerator(columnName string, generator DataGenerator) {
@@ -17,39 +21,12 @@ func (l *TableDataLoader) SetDefaultGenerators() error {
 	for _, column := range l.TableStruct.Columns {
 		dataType := strings.ToLower(column.DataType)
 
-		// Handle special case: check if column is primary key with auto-increment
-		isPrimaryAutoIncrement := false
-		for _, index := range l.TableStruct.Indexes {
-			if index.IsPrimary && len(index.Columns) == 1 && index.Columns[0] == column.Name {
-				// For MySQL, auto-increment is not directly part of the table structure
-				// For Postgres, we would check if there's a sequence attached, but that's more complex
-				if strings.Contains(dataType, "int") {
-					isPrimaryAutoIncrement = true
-					break
-				}
-			}
-		}
-
-		if isPrimaryAutoIncrement {
+		if l.isPrimaryAutoIncrement(column) {
 			// Skip auto-increment columns
 			continue
 		}
 
-		// Check if column is a foreign key
-		isForeignKey := false
-		for _, fk := range l.TableStruct.ForeignKeys {
-			for _, col := range fk.Columns {
-				if col == column.Name {
-					isForeignKey = true
-					break
-				}
-			}
-			if isForeignKey {
-				break
-			}
-		}
-
-		if isForeignKey {
+		if l.isForeignKey(column) {
 			// For foreign keys, we would ideally generate values that exist in the referenced table
 			// But for simplicity, we'll just generate plausible data
 			if strings.Contains(dataType, "int") {
@@ -62,6 +39,8 @@ func (l *TableDataLoader) SetDefaultGenerators() error {
 
 		// Set generator based on data type
 		typeTokens := strings.Split(dataType, " ")
+		// Types from DDL files carry their arguments, like varchar(20)
+		typeTokens[0], _, _ = strings.Cut(typeTokens[0], "(")
 		switch typeTokens[0] {
 		case "char", "varchar":
 			// Extract length from type if possible
@@ -140,7 +119,17 @@ func (l *TableDataLoader) SetDefaultGenerators() error {
 					}
 				}
 			}
-			l.Generators[column.Name] = NewFloatGenerator(-10000.0, 10000.0, precision)
+			limit := 10000.0
+			// Keep the values within the declared precision and scale of decimal columns
+			if column.Precision > 0 && typeTokens[0] != "double" {
+				precision = column.Scale
+				limit = min(limit, math.Pow10(column.Precision-column.Scale)-1)
+				if column.Precision == column.Scale {
+					// No integer digits, only the fractional part
+					limit = 1 - math.Pow10(-column.Scale)
+				}
+			}
+			l.Generators[column.Name] = NewFloatGenerator(-limit, limit, precision)
 
 		case "bool", "boolean":
 			l.Generators[column.Name] = NewBoolGenerator()
@@ -233,6 +222,33 @@ func (l *TableDataLoader) SetDefaultGenerators() error {
 	return nil
 }
 
+// isPrimaryAutoIncrement reports whether the column is an integer single column primary key,
+// which is assumed to be filled by the database.
+func (l *TableDataLoader) isPrimaryAutoIncrement(column domain.TableColumn) bool {
+	for _, index := range l.TableStruct.Indexes {
+		if index.IsPrimary && len(index.Columns) == 1 && index.Columns[0] == column.Name {
+			// For MySQL, auto-increment is not directly part of the table structure
+			// For Postgres, we would check if there's a sequence attached, but that's more complex
+			if strings.Contains(strings.ToLower(column.DataType), "int") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// isForeignKey reports whether the column is part of a foreign key.
+func (l *TableDataLoader) isForeignKey(column domain.TableColumn) bool {
+	for _, fk := range l.TableStruct.ForeignKeys {
+		if slices.Contains(fk.Columns, column.Name) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // parseEnumValues extracts enum values from MySQL/PostgreSQL type definition.
 func parseEnumValues(dataType string) []string {
 	// Extract values between parentheses
@@ -0,0 +1,57 @@
+package dataloader
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+)
+
+// Sink receives generated rows instead of a database connection.
+type Sink interface {
+	// Begin is called once, before any batch, with the columns that will be written.
+	Begin(table *domain.TableStructure, columns []domain.TableColumn) error
+	// WriteBatch writes a batch of rows. Values follow the column order given to Begin.
+	WriteBatch(rows [][]any) error
+	// Close flushes any pending data and releases the sink resources.
+	Close() error
+}
+
+// ExportData generates numRows random rows and writes them to the sink in batches of BatchSize rows.
+// The sink is not closed; that is left to the caller.
+func (l *TableDataLoader) ExportData(ctx context.Context, sink Sink, numRows int) error {
+	if err := sink.Begin(l.TableStruct, l.generatedColumns()); err != nil {
+		return fmt.Errorf("cannot initialize sink: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Exporting is sequential, so all the rows are counted by a single worker
+	l.stats = NewLoadStats(1)
+	stats := l.stats.Workers[0]
+	stopProgress := l.startProgress(ctx, numRows)
+	defer stopProgress()
+
+	for batch := range l.generateBatches(ctx, queueBatches(ctx, l.pendingBatches(numRows, l.BatchSize)), numRows, l.BatchSize) {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := writeBatch(sink, batch.Rows, stats); err != nil {
+			return err
+		}
+	}
+
+	return ctx.Err()
+}
+
+func writeBatch(sink Sink, batch [][]any, stats *WorkerStats) error {
+	if err := sink.WriteBatch(batch); err != nil {
+		stats.Errors.Add(1)
+		return fmt.Errorf("cannot write batch: %w", err)
+	}
+	stats.RowsCommitted.Add(int64(len(batch)))
+	stats.Batches.Add(1)
+
+	return nil
+}
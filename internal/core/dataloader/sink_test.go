@@ -0,0 +1,61 @@
+package dataloader_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/dataloader"
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+)
+
+// memorySink keeps the written rows and calls onBatch after every batch.
+type memorySink struct {
+	rows    [][]any
+	onBatch func()
+}
+
+func (s *memorySink) Begin(*domain.TableStructure, []domain.TableColumn) error { return nil }
+
+func (s *memorySink) WriteBatch(rows [][]any) error {
+	s.rows = append(s.rows, rows...)
+	if s.onBatch != nil {
+		s.onBatch()
+	}
+	return nil
+}
+
+func (s *memorySink) Close() error { return nil }
+
+func TestExportData(t *testing.T) {
+	loader := dataloader.NewTableDataLoader(nil, "mysql", planTable(), 10, parallel)
+	require.NoError(t, loader.SetDefaultGenerators())
+
+	sink := &memorySink{}
+	require.NoError(t, loader.ExportData(context.Background(), sink, 25))
+	assert.Len(t, sink.rows, 25)
+
+	// Batches are generated from the seed, so the first rows are the sample rows
+	_, sample := loader.SampleRows(3)
+	assert.Equal(t, sample, sink.rows[:3])
+
+	summary := loader.Stats()
+	assert.Equal(t, int64(25), summary.RowsCommitted)
+	assert.Equal(t, int64(3), summary.Batches)
+}
+
+func TestExportDataCanceled(t *testing.T) {
+	loader := dataloader.NewTableDataLoader(nil, "mysql", planTable(), 10, parallel)
+	require.NoError(t, loader.SetDefaultGenerators())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sink := &memorySink{onBatch: cancel}
+
+	err := loader.ExportData(ctx, sink, 1000)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, len(sink.rows), 1000)
+	assert.Equal(t, int64(len(sink.rows)), loader.Stats().RowsCommitted)
+}
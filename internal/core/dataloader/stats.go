@@ -0,0 +1,64 @@
+package dataloader
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// WorkerStats holds the counters of a single loading worker.
+type WorkerStats struct {
+	RowsCommitted atomic.Int64
+	Batches       atomic.Int64
+	Errors        atomic.Int64
+	Retries       atomic.Int64
+}
+
+// LoadStats holds the counters of all the workers of a load.
+type LoadStats struct {
+	Workers []*WorkerStats
+	Start   time.Time
+}
+
+// Summary is a point in time snapshot of the load counters.
+type Summary struct {
+	RowsCommitted int64
+	Batches       int64
+	Errors        int64
+	Retries       int64
+	Elapsed       time.Duration
+}
+
+// NewLoadStats creates the counters for the given number of workers.
+func NewLoadStats(workers int) *LoadStats {
+	stats := &LoadStats{
+		Workers: make([]*WorkerStats, workers),
+		Start:   time.Now(),
+	}
+	for i := range stats.Workers {
+		stats.Workers[i] = &WorkerStats{}
+	}
+
+	return stats
+}
+
+// Summary adds up the counters of all the workers.
+func (s *LoadStats) Summary() Summary {
+	summary := Summary{Elapsed: time.Since(s.Start)}
+	for _, worker := range s.Workers {
+		summary.RowsCommitted += worker.RowsCommitted.Load()
+		summary.Batches += worker.Batches.Load()
+		summary.Errors += worker.Errors.Load()
+		summary.Retries += worker.Retries.Load()
+	}
+
+	return summary
+}
+
+// Rate returns the average number of committed rows per second.
+func (s Summary) Rate() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+
+	return float64(s.RowsCommitted) / s.Elapsed.Seconds()
+}
@@ -0,0 +1,187 @@
+package dataloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// rowOverhead is the estimated per row storage overhead (row header, alignment, slot) in bytes.
+const rowOverhead = 24
+
+// sizeSampleRows is the number of sample rows used to estimate the row size of an empty table.
+const sizeSampleRows = 100
+
+// Target is the size a table has to reach. Only one of the fields is expected to be set.
+type Target struct {
+	Rows  int64 // Total number of rows
+	Bytes int64 // On-disk size, data and indexes
+}
+
+// TableSize is the size of a table according to the database statistics.
+type TableSize struct {
+	Rows  int64 // Estimated number of rows
+	Bytes int64 // On-disk size, data and indexes
+}
+
+// CountRows returns the exact number of rows of the table.
+func (l *TableDataLoader) CountRows(ctx context.Context) (int64, error) {
+	var count int64
+	if err := l.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+l.TableStruct.Name).Scan(&count); err != nil {
+		return 0, fmt.Errorf("cannot count rows: %w", err)
+	}
+
+	return count, nil
+}
+
+// TableSize analyzes the table to refresh its statistics and returns its estimated size.
+func (l *TableDataLoader) TableSize(ctx context.Context) (TableSize, error) {
+	conn, err := l.DB.Conn(ctx)
+	if err != nil {
+		return TableSize{}, fmt.Errorf("cannot get connection: %w", err)
+	}
+	defer conn.Close()
+
+	var size TableSize
+	switch l.DBType {
+	case "postgres":
+		if _, err = conn.ExecContext(ctx, "ANALYZE "+l.TableStruct.Name); err != nil {
+			return TableSize{}, fmt.Errorf("cannot analyze table: %w", err)
+		}
+		err = conn.QueryRowContext(ctx, `
+			SELECT GREATEST(c.reltuples, 0)::bigint, pg_total_relation_size(c.oid)
+			FROM pg_class c
+			WHERE c.oid = to_regclass($1)`, l.TableStruct.Name).Scan(&size.Rows, &size.Bytes)
+	default:
+		// MySQL 8 caches the information_schema statistics, older versions don't know the variable
+		_, _ = conn.ExecContext(ctx, "SET SESSION information_schema_stats_expiry = 0")
+		if _, err = conn.ExecContext(ctx, "ANALYZE TABLE "+l.TableStruct.Name); err != nil {
+			return TableSize{}, fmt.Errorf("cannot analyze table: %w", err)
+		}
+		err = conn.QueryRowContext(ctx, `
+			SELECT TABLE_ROWS, DATA_LENGTH + INDEX_LENGTH
+			FROM information_schema.TABLES
+			WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?`, l.TableStruct.Name).Scan(&size.Rows, &size.Bytes)
+	}
+	if err != nil {
+		return TableSize{}, fmt.Errorf("cannot get table size: %w", err)
+	}
+
+	return size, nil
+}
+
+// FillTo loads random rows until the table reaches the target. Rows targets are checked with COUNT(*).
+// Size targets are loaded in rounds: every round loads half of the rows estimated to be missing, using
+// the average row size of the table statistics, and the size is sampled again.
+func (l *TableDataLoader) FillTo(ctx context.Context, target Target, batchSize int) error {
+	restoreSettings, err := l.setGlobalSettings(ctx)
+	if err != nil {
+		return err
+	}
+	defer restoreSettings()
+
+	l.stats = NewLoadStats(l.NumGoroutines)
+	minRound := batchSize * l.NumGoroutines
+
+	// Every round generates different rows, derived from the loader seed
+	seed := l.Seed
+	defer func() { l.Seed, l.rowOffset = seed, 0 }()
+
+	stopProgress := func() {}
+	defer func() { stopProgress() }()
+
+	for round := 0; ; round++ {
+		missing, err := l.missingRows(ctx, target)
+		if err != nil {
+			return err
+		}
+		if missing <= 0 {
+			return nil
+		}
+		if round == 0 {
+			stopProgress = l.startProgress(ctx, int(missing))
+		}
+
+		rows := missing
+		if target.Bytes > 0 {
+			rows = max(missing/2, int64(minRound))
+		}
+		log.Debug().Int("round", round).Int64("missing", missing).Int64("rows", rows).Msg("Loading round")
+
+		committed := l.stats.Summary().RowsCommitted
+		l.Seed = roundSeed(seed, round)
+		if err = l.load(ctx, int(rows), batchSize); err != nil {
+			return err
+		}
+		l.rowOffset += rows
+		if l.stats.Summary().RowsCommitted == committed {
+			return errors.New("no rows were committed, the target cannot be reached")
+		}
+	}
+}
+
+// missingRows returns the estimated number of rows to load to reach the target.
+func (l *TableDataLoader) missingRows(ctx context.Context, target Target) (int64, error) {
+	if target.Bytes <= 0 {
+		count, err := l.CountRows(ctx)
+		if err != nil {
+			return 0, err
+		}
+		log.Debug().Int64("rows", count).Int64("target", target.Rows).Msg("Table rows")
+
+		return target.Rows - count, nil
+	}
+
+	start := time.Now()
+	size, err := l.TableSize(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if size.Bytes >= target.Bytes {
+		return 0, nil
+	}
+
+	rowSize := l.EstimateRowSize()
+	if size.Rows > 0 {
+		rowSize = max(size.Bytes/size.Rows, 1)
+	}
+	log.Debug().Int64("bytes", size.Bytes).Int64("rows", size.Rows).Int64("row_size", rowSize).
+		Dur("elapsed", time.Since(start)).Msg("Table size")
+
+	return max((target.Bytes-size.Bytes)/rowSize, 1), nil
+}
+
+// EstimateRowSize estimates the on-disk size of a generated row from a sample of rows.
+func (l *TableDataLoader) EstimateRowSize() int64 {
+	_, rows := l.SampleRows(sizeSampleRows)
+
+	total := int64(0)
+	for _, row := range rows {
+		total += rowOverhead
+		for _, value := range row {
+			total += valueSize(value)
+		}
+	}
+
+	return max(total/sizeSampleRows, 1)
+}
+
+// valueSize returns the approximate storage size of a generated value.
+func valueSize(value any) int64 {
+	switch v := value.(type) {
+	case nil:
+		return 0
+	case string:
+		return int64(len(v))
+	case []byte:
+		return int64(len(v))
+	case bool:
+		return 1
+	default:
+		// Numbers and timestamps
+		return 8
+	}
+}
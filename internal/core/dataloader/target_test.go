@@ -0,0 +1,18 @@
+package dataloader_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/dataloader"
+)
+
+func TestEstimateRowSize(t *testing.T) {
+	loader := dataloader.NewTableDataLoader(nil, "postgres", planTable(), batchSize, parallel)
+	require.NoError(t, loader.SetDefaultGenerators())
+
+	// Row overhead, a 10 characters string and a number
+	assert.Equal(t, int64(24+10+8), loader.EstimateRowSize())
+}
@@ -0,0 +1,164 @@
+package dataloader
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// hoursPerWeek is the number of hours of the weekly seasonality profile.
+const hoursPerWeek = 7 * 24
+
+// SequenceGenerator is implemented by the generators whose values depend on the position of the row in the
+// load, so every batch generates the same values regardless of the worker generating it.
+type SequenceGenerator interface {
+	DataGenerator
+	GenerateValueAt(r *rand.Rand, row int64) interface{}
+}
+
+// TimeSeries configures a time series: the values advance from Start by Interval per row on average, with a
+// random jitter, and the rate of rows follows the time of the day and the day of the week, in UTC.
+type TimeSeries struct {
+	Start          time.Time
+	Interval       time.Duration // Mean interval between two rows
+	Jitter         time.Duration // Maximum random offset of every value, before or after
+	DailyAmplitude float64       // Daily variation of the rate, from 0 for none to 1 for no rows at the quietest hour
+	PeakHour       int           // Busiest hour of the day
+	WeekendDip     float64       // Rate reduction on Saturdays and Sundays, from 0 for none to 1 for no rows
+}
+
+// TimeSeriesGenerator generates the values of a time series, by the position of the rows.
+type TimeSeriesGenerator struct {
+	TimeSeries
+	Format string // Time layout of the values, empty to return time.Time values
+
+	origin     time.Time                 // Monday before the start, at midnight
+	rates      [hoursPerWeek]float64     // Rate of every hour of the week, relative to the mean
+	cumulative [hoursPerWeek + 1]float64 // Mean time elapsed at the start of every hour of the week, in seconds
+	startMean  float64                   // Mean time of the start since the origin, in seconds
+	next       atomic.Int64              // Position of the next value generated without one
+}
+
+// NewTimeSeriesGenerator creates a time series generator returning values in the format, or time.Time values
+// if empty.
+func NewTimeSeriesGenerator(series TimeSeries, format string) (*TimeSeriesGenerator, error) {
+	switch {
+	case series.Interval <= 0:
+		return nil, errors.New("the time series interval must be positive")
+	case series.Jitter < 0:
+		return nil, errors.New("the time series jitter cannot be negative")
+	case series.DailyAmplitude < 0 || series.DailyAmplitude > 1:
+		return nil, errors.New("the daily amplitude must be between 0 and 1")
+	case series.WeekendDip < 0 || series.WeekendDip > 1:
+		return nil, errors.New("the weekend dip must be between 0 and 1")
+	case series.PeakHour < 0 || series.PeakHour > 23:
+		return nil, errors.New("the peak hour must be between 0 and 23")
+	}
+
+	g := &TimeSeriesGenerator{TimeSeries: series, Format: format}
+	g.Start = series.Start.UTC()
+	midnight := time.Date(g.Start.Year(), g.Start.Month(), g.Start.Day(), 0, 0, 0, 0, time.UTC)
+	g.origin = midnight.AddDate(0, 0, -(int(midnight.Weekday())+6)%7)
+
+	// The rate of every hour, at its middle, normalized so the mean rate is the one of the interval
+	total := 0.0
+	for h := range g.rates {
+		rate := 1 + series.DailyAmplitude*math.Cos(2*math.Pi*(float64(h%24)+0.5-float64(series.PeakHour))/24)
+		if h/24 >= 5 {
+			rate *= 1 - series.WeekendDip
+		}
+		g.rates[h] = rate
+		total += rate
+	}
+	for h := range g.rates {
+		g.rates[h] *= hoursPerWeek / total
+		g.cumulative[h+1] = g.cumulative[h] + g.rates[h]*3600
+	}
+
+	g.startMean = g.meanTime(g.Start.Sub(g.origin).Seconds())
+
+	return g, nil
+}
+
+// meanTime returns the mean time elapsed after the real time since the origin, within the first week.
+func (g *TimeSeriesGenerator) meanTime(seconds float64) float64 {
+	hour := min(int(seconds/3600), hoursPerWeek-1)
+
+	return g.cumulative[hour] + (seconds-float64(hour)*3600)*g.rates[hour]
+}
+
+// realTime returns the real time since the origin of the mean time since the origin.
+func (g *TimeSeriesGenerator) realTime(mean float64) float64 {
+	week := g.cumulative[hoursPerWeek]
+	weeks := math.Floor(mean / week)
+	mean -= weeks * week
+
+	// The last hour starting at or before the mean time, skipping the hours without rows
+	hour := sort.Search(hoursPerWeek, func(h int) bool { return g.cumulative[h+1] > mean })
+	hour = min(hour, hoursPerWeek-1)
+	if g.rates[hour] == 0 {
+		// Rounding errors at the end of a week without rows on Sunday nights
+		return weeks*week + float64(hour)*3600
+	}
+
+	return weeks*week + float64(hour)*3600 + (mean-g.cumulative[hour])/g.rates[hour]
+}
+
+// GenerateValue generates the value following the last one generated without a position, for the values
+// generated outside of a load, like the inserts of workloads.
+func (g *TimeSeriesGenerator) GenerateValue(r *rand.Rand) interface{} {
+	return g.GenerateValueAt(r, g.next.Add(1)-1)
+}
+
+// GenerateValueAt generates the value of the row at the position.
+func (g *TimeSeriesGenerator) GenerateValueAt(r *rand.Rand, row int64) interface{} {
+	mean := g.startMean + float64(row)*g.Interval.Seconds()
+	ts := g.origin.Add(time.Duration(g.realTime(mean) * float64(time.Second)))
+	if g.Jitter > 0 {
+		ts = ts.Add(time.Duration(r.Int64N(int64(2*g.Jitter+1))) - g.Jitter)
+	}
+
+	if g.Format == "" {
+		return ts
+	}
+
+	return ts.Format(g.Format)
+}
+
+// SetTimeSeries makes the values of the column a time series. The column must be a date or timestamp column.
+func (l *TableDataLoader) SetTimeSeries(columnName string, series TimeSeries) error {
+	for _, column := range l.TableStruct.Columns {
+		if column.Name != columnName {
+			continue
+		}
+
+		dataType := strings.ToLower(column.DataType)
+		base, _, _ := strings.Cut(strings.Split(dataType, " ")[0], "(")
+		var format string
+		switch {
+		case base == "date":
+			format = time.DateOnly
+		case base == "timestamptz" || strings.Contains(dataType, "with time zone"):
+			// The time.Time values keep their time zone
+		case base == "timestamp" || base == "datetime":
+			format = "2006-01-02 15:04:05.999999"
+		default:
+			return fmt.Errorf("the time series column %s is not a date or timestamp column", columnName)
+		}
+
+		generator, err := NewTimeSeriesGenerator(series, format)
+		if err != nil {
+			return err
+		}
+		l.Generators[columnName] = generator
+
+		return nil
+	}
+
+	return fmt.Errorf("table %s has no column %s", l.TableStruct.Name, columnName)
+}
@@ -0,0 +1,103 @@
+package dataloader_test
+
+import (
+	"context"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/dataloader"
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+)
+
+// seriesStart is the start of the test series, a Wednesday.
+func seriesStart() time.Time {
+	return time.Date(2024, 1, 3, 10, 30, 0, 0, time.UTC)
+}
+
+func TestTimeSeriesGeneratorFlat(t *testing.T) {
+	g, err := dataloader.NewTimeSeriesGenerator(dataloader.TimeSeries{Start: seriesStart(), Interval: time.Minute}, "")
+	require.NoError(t, err)
+
+	r := rand.New(rand.NewPCG(1, 2))
+	for _, row := range []int64{0, 1, 59, 10000} {
+		expected := seriesStart().Add(time.Duration(row) * time.Minute)
+		assert.WithinDuration(t, expected, g.GenerateValueAt(r, row).(time.Time), time.Millisecond, row)
+	}
+
+	// Values generated without a position follow each other
+	assert.WithinDuration(t, seriesStart(), g.GenerateValue(r).(time.Time), time.Millisecond)
+	assert.WithinDuration(t, seriesStart().Add(time.Minute), g.GenerateValue(r).(time.Time), time.Millisecond)
+}
+
+func TestTimeSeriesGeneratorSeasonality(t *testing.T) {
+	g, err := dataloader.NewTimeSeriesGenerator(dataloader.TimeSeries{
+		Start:          seriesStart(),
+		Interval:       time.Minute,
+		Jitter:         10 * time.Second,
+		DailyAmplitude: 0.8,
+		PeakHour:       14,
+		WeekendDip:     1,
+	}, time.DateTime)
+	require.NoError(t, err)
+
+	r := rand.New(rand.NewPCG(1, 2))
+	hours := map[int]int{}
+	previous := seriesStart().Add(-time.Minute)
+	rows := int64(4 * 7 * 24 * 60) // Four weeks
+	for row := range rows {
+		ts, err := time.Parse(time.DateTime, g.GenerateValueAt(r, row).(string))
+		require.NoError(t, err)
+
+		// Roughly chronological, within the jitter
+		assert.False(t, ts.Before(previous.Add(-20*time.Second)), row)
+		previous = ts
+
+		assert.NotContains(t, []time.Weekday{time.Saturday, time.Sunday}, ts.Add(10*time.Second).Weekday())
+		hours[ts.Hour()]++
+	}
+
+	// Peak at 14:00, quietest at 02:00, and the same mean rate
+	assert.Greater(t, hours[14], 4*hours[2])
+	assert.WithinDuration(t, seriesStart().Add(time.Duration(rows)*time.Minute), previous, 24*time.Hour)
+}
+
+func TestNewTimeSeriesGeneratorErrors(t *testing.T) {
+	for name, series := range map[string]dataloader.TimeSeries{
+		"no interval":     {},
+		"negative jitter": {Interval: time.Second, Jitter: -time.Second},
+		"amplitude":       {Interval: time.Second, DailyAmplitude: 1.5},
+		"weekend dip":     {Interval: time.Second, WeekendDip: -0.1},
+		"peak hour":       {Interval: time.Second, PeakHour: 24},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := dataloader.NewTimeSeriesGenerator(series, "")
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestSetTimeSeries(t *testing.T) {
+	table := planTable()
+	table.Columns = append(table.Columns, domain.TableColumn{Name: "created_at", DataType: "datetime"})
+	loader := dataloader.NewTableDataLoader(nil, "mysql", table, 10, parallel)
+	require.NoError(t, loader.SetDefaultGenerators())
+
+	series := dataloader.TimeSeries{Start: seriesStart(), Interval: time.Second}
+	require.ErrorContains(t, loader.SetTimeSeries("missing", series), "no column missing")
+	require.ErrorContains(t, loader.SetTimeSeries("price", series), "not a date or timestamp")
+	require.NoError(t, loader.SetTimeSeries("created_at", series))
+
+	// The values follow the position of the rows in the load, across the batches
+	sink := &memorySink{}
+	require.NoError(t, loader.ExportData(context.Background(), sink, 25))
+	for i, row := range sink.rows {
+		assert.Equal(t, seriesStart().Add(time.Duration(i)*time.Second).Format(time.DateTime), row[2], i)
+	}
+
+	_, sample := loader.SampleRows(3)
+	assert.Equal(t, sample, sink.rows[:3])
+}
@@ -0,0 +1,265 @@
+package dataloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Workload operations.
+const (
+	OpInsert = "insert"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+// WorkloadMix is the relative weight of every operation of a mixed workload.
+type WorkloadMix struct {
+	Insert int
+	Update int
+	Delete int
+}
+
+// WorkloadOptions configures a mixed DML workload.
+type WorkloadOptions struct {
+	Mix           WorkloadMix
+	UpdateColumns []string      // Columns regenerated by updates, all the generated non key columns if empty
+	Operations    int           // Total number of operations, ignored if Duration is set
+	Duration      time.Duration // Run the workload for this long
+}
+
+// OperationStats holds the counters and latencies of an operation.
+type OperationStats struct {
+	Count   atomic.Int64
+	Errors  atomic.Int64
+	Misses  atomic.Int64 // Updates and deletes that matched no row
+	Latency LatencyHistogram
+}
+
+// WorkloadStats holds the stats of every operation of a workload.
+type WorkloadStats map[string]*OperationStats
+
+// ParseWorkloadMix parses a workload mix like insert:60,update:30,delete:10.
+func ParseWorkloadMix(spec string) (WorkloadMix, error) {
+	var mix WorkloadMix
+	for _, part := range strings.Split(spec, ",") {
+		op, weight, found := strings.Cut(strings.TrimSpace(part), ":")
+		value, err := strconv.Atoi(weight)
+		if !found || err != nil || value < 0 {
+			return WorkloadMix{}, fmt.Errorf("invalid workload operation %q, expected operation:weight", part)
+		}
+
+		switch strings.ToLower(op) {
+		case OpInsert:
+			mix.Insert = value
+		case OpUpdate:
+			mix.Update = value
+		case OpDelete:
+			mix.Delete = value
+		default:
+			return WorkloadMix{}, fmt.Errorf("unknown workload operation %q", op)
+		}
+	}
+
+	if mix.Insert+mix.Update+mix.Delete == 0 {
+		return WorkloadMix{}, errors.New("the workload mix has no operations")
+	}
+
+	return mix, nil
+}
+
+// pick returns an operation with a probability proportional to its weight.
+func (m WorkloadMix) pick(r *rand.Rand) string {
+	n := r.IntN(m.Insert + m.Update + m.Delete)
+	switch {
+	case n < m.Insert:
+		return OpInsert
+	case n < m.Insert+m.Update:
+		return OpUpdate
+	default:
+		return OpDelete
+	}
+}
+
+// workload holds the statements and state shared by the workers of a workload.
+type workload struct {
+	options     WorkloadOptions
+	insertQuery string
+	inserted    *rowGenerator // Generated columns of the inserted rows
+	updateQuery string
+	deleteQuery string
+	updated     []DataGenerator // Generators of the updated columns, in SET order
+	keys        *keySampler
+	stats       WorkloadStats
+	issued      atomic.Int64
+}
+
+// RunWorkload runs a mix of INSERT, UPDATE and DELETE statements, each one in its own transaction, with
+// NumGoroutines workers. Updates and deletes use primary keys sampled from the live table. Failed statements
+// are only counted, while a worker failing to build a statement, e.g. when the keys cannot be sampled, stops
+// the workload and its error is returned with the stats so far.
+func (l *TableDataLoader) RunWorkload(ctx context.Context, options WorkloadOptions) (WorkloadStats, error) {
+	w, err := l.newWorkload(options)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Duration)
+		defer cancel()
+	}
+
+	workersCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	err = l.runWorkers(workersCtx, func(worker int) error {
+		err := l.runWorkloadWorker(workersCtx, w, rand.New(rand.NewPCG(l.Seed, uint64(worker))))
+		if err != nil {
+			// Stop the other workers
+			cancel()
+		}
+		return err
+	})
+	if err != nil && !errors.Is(err, workersCtx.Err()) {
+		return w.stats, err
+	}
+
+	return w.stats, nil
+}
+
+func (l *TableDataLoader) newWorkload(options WorkloadOptions) (*workload, error) {
+	key := l.primaryKey()
+	if len(key) == 0 && options.Mix.Update+options.Mix.Delete > 0 {
+		return nil, fmt.Errorf("table %s has no primary key to update or delete rows", l.TableStruct.Name)
+	}
+
+	updateColumns := options.UpdateColumns
+	if len(updateColumns) == 0 {
+		for _, column := range l.generatedColumns() {
+			if !slices.Contains(key, column.Name) {
+				updateColumns = append(updateColumns, column.Name)
+			}
+		}
+	}
+
+	w := &workload{
+		options:     options,
+		insertQuery: l.InsertQuery(),
+		inserted:    l.newRowGenerator(),
+		keys:        l.newKeySampler(key),
+		stats: WorkloadStats{
+			OpInsert: {},
+			OpUpdate: {},
+			OpDelete: {},
+		},
+	}
+
+	set := make([]string, 0, len(updateColumns))
+	for _, name := range updateColumns {
+		generator, ok := l.Generators[name]
+		if !ok {
+			return nil, fmt.Errorf("column %s has no generator to update it", name)
+		}
+		set = append(set, name+" = ?")
+		w.updated = append(w.updated, generator)
+	}
+	if len(set) == 0 && options.Mix.Update > 0 {
+		return nil, errors.New("there are no columns to update")
+	}
+
+	where := make([]string, len(key))
+	for i, name := range key {
+		where[i] = name + " = ?"
+	}
+	w.updateQuery = l.rebind(fmt.Sprintf("UPDATE %s SET %s WHERE %s",
+		l.TableStruct.Name, strings.Join(set, ", "), strings.Join(where, " AND ")))
+	w.deleteQuery = l.rebind(fmt.Sprintf("DELETE FROM %s WHERE %s",
+		l.TableStruct.Name, strings.Join(where, " AND ")))
+
+	return w, nil
+}
+
+// runWorkloadWorker runs statements until the workload ends. It returns nil once the operations are issued or
+// the context is done, and the error of a statement that cannot be built otherwise.
+func (l *TableDataLoader) runWorkloadWorker(ctx context.Context, w *workload, r *rand.Rand) error {
+	l.Metrics.WorkerStarted()
+	defer l.Metrics.WorkerStopped()
+
+	for ctx.Err() == nil {
+		if w.options.Duration == 0 && w.issued.Add(1) > int64(w.options.Operations) {
+			return nil
+		}
+		if l.Limiter.Wait(ctx, 1) != nil {
+			// Only canceled by the context
+			return nil
+		}
+
+		op := w.options.Mix.pick(r)
+		query, args, err := l.workloadStatement(ctx, w, op, r)
+		if errors.Is(err, ErrNoKeys) {
+			// Nothing to update or delete yet, wait for inserts before sampling again
+			w.stats[op].Misses.Add(1)
+			sleep(ctx, noKeysBackoff)
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("%s failed: %w", op, err)
+		}
+
+		// Let the statement finish on shutdown, like the batches of a load
+		start := time.Now()
+		result, err := l.DB.ExecContext(context.WithoutCancel(ctx), query, args...)
+		w.stats[op].Latency.Record(time.Since(start))
+		w.stats[op].Count.Add(1)
+		if err != nil {
+			w.stats[op].Errors.Add(1)
+			l.Metrics.StatementError(sqlState(err))
+			log.Debug().Err(err).Str("operation", op).Msg("workload statement failed")
+			continue
+		}
+		if affected, affectedErr := result.RowsAffected(); affectedErr == nil && affected == 0 {
+			w.stats[op].Misses.Add(1)
+		}
+	}
+
+	return nil
+}
+
+// workloadStatement returns the statement and arguments of an operation.
+func (l *TableDataLoader) workloadStatement(
+	ctx context.Context,
+	w *workload,
+	op string,
+	r *rand.Rand,
+) (string, []any, error) {
+	if op == OpInsert {
+		return w.insertQuery, l.generateRow(r, w.inserted, -1, true), nil
+	}
+
+	key, err := w.keys.Sample(ctx, r)
+	if err != nil {
+		return "", nil, err
+	}
+	if op == OpDelete {
+		return w.deleteQuery, key, nil
+	}
+
+	args := make([]any, 0, len(w.updated)+len(key))
+	for _, generator := range w.updated {
+		args = append(args, generator.GenerateValue(r))
+	}
+
+	return w.updateQuery, append(args, key...), nil
+}
@@ -0,0 +1,65 @@
+package dataloader_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/dataloader"
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+)
+
+func TestParseWorkloadMix(t *testing.T) {
+	mix, err := dataloader.ParseWorkloadMix("insert:60, update:30,delete:10")
+	require.NoError(t, err)
+	assert.Equal(t, dataloader.WorkloadMix{Insert: 60, Update: 30, Delete: 10}, mix)
+
+	mix, err = dataloader.ParseWorkloadMix("update:1")
+	require.NoError(t, err)
+	assert.Equal(t, dataloader.WorkloadMix{Update: 1}, mix)
+
+	for _, spec := range []string{"", "insert", "insert:-1", "select:10", "insert:0,delete:0"} {
+		_, err = dataloader.ParseWorkloadMix(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestRunWorkloadValidation(t *testing.T) {
+	table := planTable()
+	table.Indexes = nil
+	loader := dataloader.NewTableDataLoader(nil, "mysql", table, batchSize, parallel)
+	require.NoError(t, loader.SetDefaultGenerators())
+
+	_, err := loader.RunWorkload(context.Background(), dataloader.WorkloadOptions{
+		Mix: dataloader.WorkloadMix{Insert: 1, Delete: 1},
+	})
+	require.ErrorContains(t, err, "no primary key")
+
+	loader.TableStruct.Indexes = []domain.TableIndex{{Name: "PRIMARY", Columns: []string{"id"}, IsPrimary: true}}
+	_, err = loader.RunWorkload(context.Background(), dataloader.WorkloadOptions{
+		Mix:           dataloader.WorkloadMix{Update: 1},
+		UpdateColumns: []string{"missing"},
+	})
+	require.ErrorContains(t, err, "no generator")
+}
+
+func TestRunWorkloadWorkerError(t *testing.T) {
+	// Nothing listens on the port, so the keys of the updates cannot be sampled
+	db, err := sql.Open("mysql", "root:root@tcp(127.0.0.1:1)/testdb")
+	require.NoError(t, err)
+	defer db.Close()
+
+	table := planTable()
+	loader := dataloader.NewTableDataLoader(db, "mysql", table, batchSize, 2)
+	require.NoError(t, loader.SetDefaultGenerators())
+
+	stats, err := loader.RunWorkload(context.Background(), dataloader.WorkloadOptions{
+		Mix:        dataloader.WorkloadMix{Update: 1},
+		Operations: 100,
+	})
+	require.ErrorContains(t, err, "cannot sample keys")
+	assert.Zero(t, stats[dataloader.OpUpdate].Count.Load())
+}
@@ -0,0 +1,43 @@
+package domain
+
+import "strings"
+
+// ParseLiteralList parses a comma separated list of SQL literals, like the partition bounds of the catalogs:
+// strings are unquoted, NULL is nil and other literals, like numbers or MAXVALUE, are returned as they are.
+func ParseLiteralList(list string) []*string {
+	var literals []*string
+	for rest := strings.TrimSpace(list); rest != ""; {
+		var literal string
+		if rest[0] == '\'' {
+			var value strings.Builder
+			i := 1
+			for ; i < len(rest); i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+				} else if rest[i] == '\'' {
+					if i+1 >= len(rest) || rest[i+1] != '\'' {
+						break
+					}
+					i++
+				}
+				value.WriteByte(rest[i])
+			}
+			literal = value.String()
+			rest = rest[min(i+1, len(rest)):]
+			_, rest, _ = strings.Cut(rest, ",")
+		} else {
+			literal, rest, _ = strings.Cut(rest, ",")
+			literal = strings.TrimSpace(literal)
+			if strings.EqualFold(literal, "NULL") {
+				literals = append(literals, nil)
+				rest = strings.TrimSpace(rest)
+				continue
+			}
+		}
+
+		literals = append(literals, &literal)
+		rest = strings.TrimSpace(rest)
+	}
+
+	return literals
+}
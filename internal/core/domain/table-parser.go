@@ -2,32 +2,61 @@ package domain
 
 // TableColumn represents a column in a database table.
 type TableColumn struct {
-	Name     string
-	DataType string
-	Nullable bool
-	Default  string
+	Name      string `json:"name"                yaml:"name"`
+	DataType  string `json:"data_type"           yaml:"data_type"`
+	Nullable  bool   `json:"nullable"            yaml:"nullable"`
+	Default   string `json:"default,omitempty"   yaml:"default,omitempty"`
+	Precision int    `json:"precision,omitempty" yaml:"precision,omitempty"` // Numeric precision, 0 if unknown or not numeric
+	Scale     int    `json:"scale,omitempty"     yaml:"scale,omitempty"`     // Numeric scale, 0 if unknown or not numeric
 }
 
 // TableIndex represents an index in a database table.
 type TableIndex struct {
-	Name      string
-	Columns   []string
-	IsUnique  bool
-	IsPrimary bool
+	Name      string   `json:"name"               yaml:"name"`
+	Columns   []string `json:"columns"            yaml:"columns"`
+	IsUnique  bool     `json:"unique"             yaml:"unique"`
+	IsPrimary bool     `json:"primary"            yaml:"primary"`
+	Prefixes  []int    `json:"prefixes,omitempty" yaml:"prefixes,omitempty"` // MySQL key prefix length by column, 0 for the whole column
 }
 
 // ForeignKey represents a foreign key constraint in a database table.
 type ForeignKey struct {
-	Name              string
-	Columns           []string
-	ReferencedTable   string
-	ReferencedColumns []string
+	Name              string   `json:"name"               yaml:"name"`
+	Columns           []string `json:"columns"            yaml:"columns"`
+	ReferencedTable   string   `json:"referenced_table"   yaml:"referenced_table"`
+	ReferencedColumns []string `json:"referenced_columns" yaml:"referenced_columns"`
+}
+
+// Partitioning methods.
+const (
+	PartitionRange = "range"
+	PartitionList  = "list"
+	PartitionHash  = "hash"
+)
+
+// Partition represents a partition of a table. Range partitions hold the key values from From, inclusive, to
+// To, exclusive, where an empty bound is unbounded. List partitions hold the key values in Values, with the
+// NULL value as a nil pointer. The default partition holds the values of no other partition.
+type Partition struct {
+	Name      string    `json:"name"              yaml:"name"`
+	From      string    `json:"from,omitempty"    yaml:"from,omitempty"`
+	To        string    `json:"to,omitempty"      yaml:"to,omitempty"`
+	Values    []*string `json:"values,omitempty"  yaml:"values,omitempty"`
+	IsDefault bool      `json:"default,omitempty" yaml:"default,omitempty"`
+}
+
+// Partitioning represents the partitioning of a table.
+type Partitioning struct {
+	Method     string      `json:"method"     yaml:"method"`     // range, list or hash
+	Expression string      `json:"expression" yaml:"expression"` // Partition key, like created_at or year(created_at)
+	Partitions []Partition `json:"partitions" yaml:"partitions"`
 }
 
 // TableStructure represents the structure of a database table.
 type TableStructure struct {
-	Name        string
-	Columns     []TableColumn
-	Indexes     []TableIndex
-	ForeignKeys []ForeignKey
+	Name         string        `json:"name"                   yaml:"name"`
+	Columns      []TableColumn `json:"columns"                yaml:"columns"`
+	Indexes      []TableIndex  `json:"indexes"                yaml:"indexes"`
+	ForeignKeys  []ForeignKey  `json:"foreign_keys"           yaml:"foreign_keys"`
+	Partitioning *Partitioning `json:"partitioning,omitempty" yaml:"partitioning,omitempty"` // Nil if not partitioned
 }
@@ -0,0 +1,160 @@
+// Package metrics exposes the data loader counters as Prometheus metrics
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace       = "random_data_loader"
+	shutdownTimeout = 5 * time.Second
+)
+
+// Metrics holds the Prometheus collectors of a load.
+// All the methods are safe to call on a nil *Metrics, so instrumentation can stay unconditional.
+type Metrics struct {
+	rowsGenerated    prometheus.Counter
+	rowsCommitted    prometheus.Counter
+	batchDuration    prometheus.Histogram
+	statementErrors  *prometheus.CounterVec
+	activeWorkers    prometheus.Gauge
+	generatorSeconds *prometheus.HistogramVec
+}
+
+// New creates the collectors and registers them in reg.
+func New(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		rowsGenerated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rows_generated_total",
+			Help:      "Number of rows generated.",
+		}),
+		rowsCommitted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rows_committed_total",
+			Help:      "Number of rows committed to the database.",
+		}),
+		batchDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "batch_insert_duration_seconds",
+			Help:      "Time spent inserting and committing a batch.",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 15),
+		}),
+		statementErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "statement_errors_total",
+			Help:      "Number of failed statements by SQL state.",
+		}, []string{"sql_state"}),
+		activeWorkers: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "active_workers",
+			Help:      "Number of workers currently loading data.",
+		}),
+		generatorSeconds: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "generator_duration_seconds",
+			Help:      "Time spent generating a value by column.",
+			Buckets:   prometheus.ExponentialBuckets(1e-7, 4, 10),
+		}, []string{"column"}),
+	}
+
+	reg.MustRegister(
+		m.rowsGenerated,
+		m.rowsCommitted,
+		m.batchDuration,
+		m.statementErrors,
+		m.activeWorkers,
+		m.generatorSeconds,
+	)
+
+	return m
+}
+
+// RowGenerated counts a generated row.
+func (m *Metrics) RowGenerated() {
+	if m == nil {
+		return
+	}
+	m.rowsGenerated.Inc()
+}
+
+// GeneratorObserver returns the histogram of the time spent generating a value of the column, in seconds.
+// It is resolved once per load, as looking up the label values is slower than most generators.
+// It returns nil on a nil *Metrics.
+func (m *Metrics) GeneratorObserver(column string) prometheus.Observer {
+	if m == nil {
+		return nil
+	}
+	return m.generatorSeconds.WithLabelValues(column)
+}
+
+// ObserveCommit records a committed batch and the time spent inserting and committing it.
+func (m *Metrics) ObserveCommit(rows int, elapsed time.Duration) {
+	if m == nil {
+		return
+	}
+	m.rowsCommitted.Add(float64(rows))
+	m.batchDuration.Observe(elapsed.Seconds())
+}
+
+// StatementError counts a failed statement.
+func (m *Metrics) StatementError(sqlState string) {
+	if m == nil {
+		return
+	}
+	m.statementErrors.WithLabelValues(sqlState).Inc()
+}
+
+// WorkerStarted increments the number of active workers.
+func (m *Metrics) WorkerStarted() {
+	if m == nil {
+		return
+	}
+	m.activeWorkers.Inc()
+}
+
+// WorkerStopped decrements the number of active workers.
+func (m *Metrics) WorkerStopped() {
+	if m == nil {
+		return
+	}
+	m.activeWorkers.Dec()
+}
+
+// Serve exposes the metrics of reg, plus the Go runtime and process metrics, on addr under /metrics
+// until the context is done.
+func Serve(ctx context.Context, addr string, reg *prometheus.Registry) error {
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(reg, promhttp.HandlerOpts{Registry: reg}))
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: shutdownTimeout,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package metrics_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/metrics"
+)
+
+func TestMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := metrics.New(reg)
+
+	m.RowGenerated()
+	m.RowGenerated()
+	m.ObserveCommit(2, 10*time.Millisecond)
+	m.StatementError("40001")
+	m.WorkerStarted()
+	m.GeneratorObserver("name").Observe(time.Millisecond.Seconds())
+
+	expected := `
+# HELP random_data_loader_rows_committed_total Number of rows committed to the database.
+# TYPE random_data_loader_rows_committed_total counter
+random_data_loader_rows_committed_total 2
+# HELP random_data_loader_rows_generated_total Number of rows generated.
+# TYPE random_data_loader_rows_generated_total counter
+random_data_loader_rows_generated_total 2
+# HELP random_data_loader_statement_errors_total Number of failed statements by SQL state.
+# TYPE random_data_loader_statement_errors_total counter
+random_data_loader_statement_errors_total{sql_state="40001"} 1
+# HELP random_data_loader_active_workers Number of workers currently loading data.
+# TYPE random_data_loader_active_workers gauge
+random_data_loader_active_workers 1
+`
+	err := testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"random_data_loader_rows_committed_total",
+		"random_data_loader_rows_generated_total",
+		"random_data_loader_statement_errors_total",
+		"random_data_loader_active_workers",
+	)
+	require.NoError(t, err)
+
+	count, err := testutil.GatherAndCount(reg,
+		"random_data_loader_generator_duration_seconds",
+		"random_data_loader_batch_insert_duration_seconds",
+	)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestNilMetrics(t *testing.T) {
+	var m *metrics.Metrics
+
+	assert.NotPanics(t, func() {
+		m.RowGenerated()
+		m.ObserveCommit(1, time.Second)
+		m.StatementError("unknown")
+		m.WorkerStarted()
+		m.WorkerStopped()
+		assert.Nil(t, m.GeneratorObserver("id"))
+	})
+}
@@ -0,0 +1,155 @@
+// Package arrowsink implements dataloader sinks that write generated rows as Parquet or Arrow IPC files
+package arrowsink
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+)
+
+// Supported output formats.
+const (
+	FormatParquet = "parquet"
+	FormatArrow   = "arrow"
+)
+
+// recordWriter is implemented by both the Parquet and the Arrow IPC file writers.
+type recordWriter interface {
+	Write(rec arrow.Record) error
+	Close() error
+}
+
+// Sink writes every batch of rows as one Parquet row group or one Arrow IPC record batch.
+type Sink struct {
+	file        *os.File
+	format      string
+	compression string
+	batchSize   int
+
+	builder   *array.RecordBuilder
+	appenders []appendFunc
+	writer    recordWriter
+}
+
+// New creates the file at path and returns a sink writing rows in the given format.
+// Compression is applied by the file format itself: Parquet supports gzip and zstd, Arrow IPC only zstd.
+func New(path, format, compression string, batchSize int) (*Sink, error) {
+	switch {
+	case format != FormatParquet && format != FormatArrow:
+		return nil, fmt.Errorf("unsupported output format: %s", format)
+	case compression != "" && compression != "none" && compression != "gzip" && compression != "zstd":
+		return nil, fmt.Errorf("unsupported compression: %s", compression)
+	case format == FormatArrow && compression == "gzip":
+		return nil, errors.New("arrow files do not support gzip compression")
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Sink{
+		file:        file,
+		format:      format,
+		compression: compression,
+		batchSize:   batchSize,
+	}, nil
+}
+
+// Begin maps the columns to an Arrow schema and writes the file header.
+func (s *Sink) Begin(_ *domain.TableStructure, columns []domain.TableColumn) error {
+	schema := newSchema(columns)
+	s.builder = array.NewRecordBuilder(memory.DefaultAllocator, schema)
+
+	s.appenders = make([]appendFunc, len(columns))
+	for i := range columns {
+		appender, err := newAppender(s.builder.Field(i))
+		if err != nil {
+			return fmt.Errorf("column %s: %w", columns[i].Name, err)
+		}
+		s.appenders[i] = appender
+	}
+
+	var err error
+	if s.format == FormatParquet {
+		s.writer, err = s.newParquetWriter(schema)
+	} else {
+		s.writer, err = s.newArrowWriter(schema)
+	}
+
+	return err
+}
+
+// WriteBatch converts the rows to a record and writes it.
+func (s *Sink) WriteBatch(rows [][]any) error {
+	for _, row := range rows {
+		for i, value := range row {
+			if value == nil {
+				s.builder.Field(i).AppendNull()
+				continue
+			}
+			if err := s.appenders[i](value); err != nil {
+				return fmt.Errorf("column %s: %w", s.builder.Schema().Field(i).Name, err)
+			}
+		}
+	}
+
+	rec := s.builder.NewRecord()
+	defer rec.Release()
+
+	return s.writer.Write(rec)
+}
+
+// Close writes the file footer and closes the file.
+func (s *Sink) Close() error {
+	var err error
+	if s.writer != nil {
+		err = s.writer.Close()
+	}
+	if s.builder != nil {
+		s.builder.Release()
+	}
+
+	// The Parquet writer closes the file by itself
+	if s.format == FormatParquet && s.writer != nil {
+		return err
+	}
+
+	return errors.Join(err, s.file.Close())
+}
+
+func (s *Sink) newParquetWriter(schema *arrow.Schema) (recordWriter, error) {
+	codec := compress.Codecs.Uncompressed
+	switch s.compression {
+	case "gzip":
+		codec = compress.Codecs.Gzip
+	case "zstd":
+		codec = compress.Codecs.Zstd
+	}
+
+	props := parquet.NewWriterProperties(
+		parquet.WithCompression(codec),
+		parquet.WithMaxRowGroupLength(int64(s.batchSize)),
+	)
+
+	return pqarrow.NewFileWriter(schema, s.file, props, pqarrow.NewArrowWriterProperties(pqarrow.WithStoreSchema()))
+}
+
+func (s *Sink) newArrowWriter(schema *arrow.Schema) (recordWriter, error) {
+	opts := []ipc.Option{ipc.WithSchema(schema)}
+	if s.compression == "zstd" {
+		opts = append(opts, ipc.WithZstd())
+	}
+
+	return ipc.NewFileWriter(s.file, opts...)
+}
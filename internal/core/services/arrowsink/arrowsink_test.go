@@ -0,0 +1,108 @@
+package arrowsink_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+	"github.com/cfsalguero/random_data_loader/internal/core/services/arrowsink"
+)
+
+var testColumns = []domain.TableColumn{ //nolint:gochecknoglobals // Test fixture.
+	{Name: "id", DataType: "bigint"},
+	{Name: "price", DataType: "decimal(10,2)", Precision: 10, Scale: 2},
+	{Name: "created", DataType: "timestamp with time zone"},
+	{Name: "updated", DataType: "datetime"},
+	{Name: "uid", DataType: "uuid"},
+	{Name: "doc", DataType: "json"},
+	{Name: "data", DataType: "bytea"},
+}
+
+func writeFile(t *testing.T, format string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "out."+format)
+	sink, err := arrowsink.New(path, format, "zstd", 2)
+	require.NoError(t, err)
+
+	created := time.Date(2020, 2, 3, 4, 5, 6, 0, time.UTC)
+	batches := [][][]any{
+		{
+			{int64(1), 12.34, created, "2020-02-03 04:05:06", "0b0c4bc4-2d1a-4c1e-9a55-2a4e4d1d4f6a", `{"a": 1}`, []byte{1}},
+			{int64(2), nil, created, nil, nil, nil, nil},
+		},
+		{
+			{int64(3), -5.5, created, "2021-01-01 00:00:00", "6c0f2d8c-9b7e-4d5c-8f41-1f0d6f1f2a3b", `[]`, []byte{}},
+		},
+	}
+
+	require.NoError(t, sink.Begin(&domain.TableStructure{Name: "t"}, testColumns))
+	for _, batch := range batches {
+		require.NoError(t, sink.WriteBatch(batch))
+	}
+	require.NoError(t, sink.Close())
+
+	return path
+}
+
+func TestParquetSink(t *testing.T) {
+	path := writeFile(t, arrowsink.FormatParquet)
+
+	reader, err := file.OpenParquetFile(path, false)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	assert.Equal(t, 2, reader.NumRowGroups())
+	assert.Equal(t, int64(3), reader.NumRows())
+
+	schema := reader.MetaData().Schema
+	assert.Equal(t, "Decimal(precision=10, scale=2)", schema.Column(1).LogicalType().String())
+	assert.Equal(t, "UUID", schema.Column(4).LogicalType().String())
+
+	fileReader, err := pqarrow.NewFileReader(reader, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	require.NoError(t, err)
+	table, err := fileReader.ReadTable(t.Context())
+	require.NoError(t, err)
+	defer table.Release()
+
+	created, ok := table.Schema().Field(2).Type.(*arrow.TimestampType)
+	require.True(t, ok)
+	assert.Equal(t, "UTC", created.TimeZone)
+
+	updated, ok := table.Schema().Field(3).Type.(*arrow.TimestampType)
+	require.True(t, ok)
+	assert.Empty(t, updated.TimeZone)
+}
+
+func TestArrowSink(t *testing.T) {
+	f, err := os.Open(writeFile(t, arrowsink.FormatArrow))
+	require.NoError(t, err)
+	defer f.Close()
+
+	reader, err := ipc.NewFileReader(f)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	require.Equal(t, 2, reader.NumRecords())
+
+	rec, err := reader.Record(0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), rec.NumRows())
+	assert.Equal(t, `[1 2]`, rec.Column(0).String())
+	assert.True(t, rec.Column(1).IsNull(1))
+}
+
+func TestUnsupportedCompression(t *testing.T) {
+	_, err := arrowsink.New(filepath.Join(t.TempDir(), "out"), arrowsink.FormatArrow, "gzip", 10)
+	require.Error(t, err)
+}
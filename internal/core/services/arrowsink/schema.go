@@ -0,0 +1,235 @@
+package arrowsink
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/decimal128"
+	"github.com/apache/arrow-go/v18/arrow/extensions"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+)
+
+const (
+	// Used for decimal columns whose precision is not known.
+	defaultDecimalPrecision = 38
+	defaultDecimalScale     = 2
+)
+
+// appendFunc appends a generated value to a column builder.
+type appendFunc func(value any) error
+
+// newSchema builds the Arrow schema for the generated columns.
+func newSchema(columns []domain.TableColumn) *arrow.Schema {
+	fields := make([]arrow.Field, len(columns))
+	for i, column := range columns {
+		fields[i] = arrow.Field{Name: column.Name, Type: columnType(column), Nullable: true}
+	}
+
+	return arrow.NewSchema(fields, nil)
+}
+
+// columnType maps a table column to its Arrow type.
+//
+//nolint:cyclop // It is a plain type mapping.
+func columnType(column domain.TableColumn) arrow.DataType {
+	dataType := strings.ToLower(column.DataType)
+	unsigned := strings.Contains(dataType, "unsigned")
+
+	// Types from DDL files carry their arguments, like decimal(10,2)
+	name, _, _ := strings.Cut(strings.Split(dataType, " ")[0], "(")
+	switch name {
+	case "tinyint":
+		if unsigned {
+			return arrow.PrimitiveTypes.Uint8
+		}
+		return arrow.PrimitiveTypes.Int8
+	case "smallint":
+		if unsigned {
+			return arrow.PrimitiveTypes.Uint16
+		}
+		return arrow.PrimitiveTypes.Int16
+	case "mediumint", "int", "integer":
+		if unsigned {
+			return arrow.PrimitiveTypes.Uint32
+		}
+		return arrow.PrimitiveTypes.Int32
+	case "bigint":
+		if unsigned {
+			return arrow.PrimitiveTypes.Uint64
+		}
+		return arrow.PrimitiveTypes.Int64
+	case "float", "real":
+		return arrow.PrimitiveTypes.Float32
+	case "double":
+		return arrow.PrimitiveTypes.Float64
+	case "decimal", "numeric":
+		if column.Precision > 0 {
+			//nolint:gosec // Precision and scale are bounded by the database.
+			return &arrow.Decimal128Type{Precision: int32(column.Precision), Scale: int32(column.Scale)}
+		}
+		return &arrow.Decimal128Type{Precision: defaultDecimalPrecision, Scale: defaultDecimalScale}
+	case "bool", "boolean":
+		return arrow.FixedWidthTypes.Boolean
+	case "date":
+		return arrow.FixedWidthTypes.Date32
+	case "timestamp", "datetime":
+		if strings.Contains(dataType, "with time zone") || strings.Contains(dataType, "timestamptz") {
+			return arrow.FixedWidthTypes.Timestamp_us
+		}
+		return &arrow.TimestampType{Unit: arrow.Microsecond}
+	case "uuid":
+		return extensions.NewUUIDType()
+	case "blob", "binary", "bytea":
+		return arrow.BinaryTypes.Binary
+	default:
+		// JSON and everything else is stored as a string
+		return arrow.BinaryTypes.String
+	}
+}
+
+// newAppender returns the function converting generated values for the given column builder.
+//
+//nolint:cyclop // It is a plain type mapping.
+func newAppender(b array.Builder) (appendFunc, error) {
+	switch builder := b.(type) {
+	case *array.Int8Builder:
+		return appendInt(builder.Append), nil
+	case *array.Int16Builder:
+		return appendInt(builder.Append), nil
+	case *array.Int32Builder:
+		return appendInt(builder.Append), nil
+	case *array.Int64Builder:
+		return appendInt(builder.Append), nil
+	case *array.Uint8Builder:
+		return appendInt(builder.Append), nil
+	case *array.Uint16Builder:
+		return appendInt(builder.Append), nil
+	case *array.Uint32Builder:
+		return appendInt(builder.Append), nil
+	case *array.Uint64Builder:
+		return appendInt(builder.Append), nil
+	case *array.Float32Builder:
+		return appendFloat(builder.Append), nil
+	case *array.Float64Builder:
+		return appendFloat(builder.Append), nil
+	case *array.Decimal128Builder:
+		return appendDecimal(builder), nil
+	case *array.BooleanBuilder:
+		return appendTyped(builder.Append), nil
+	case *array.Date32Builder:
+		return func(value any) error {
+			v, ok := value.(time.Time)
+			if !ok {
+				return unexpectedType(value, builder)
+			}
+			builder.Append(arrow.Date32FromTime(v))
+			return nil
+		}, nil
+	case *array.TimestampBuilder:
+		return appendTimestamp(builder), nil
+	case *extensions.UUIDBuilder:
+		return func(value any) error {
+			v, ok := value.(string)
+			if !ok {
+				return unexpectedType(value, builder)
+			}
+			return builder.AppendValueFromString(v)
+		}, nil
+	case *array.BinaryBuilder:
+		return appendTyped(builder.Append), nil
+	case *array.StringBuilder:
+		return func(value any) error {
+			if v, ok := value.(string); ok {
+				builder.Append(v)
+			} else {
+				builder.Append(fmt.Sprint(value))
+			}
+			return nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported column builder %T", b)
+	}
+}
+
+func appendInt[T int8 | int16 | int32 | int64 | uint8 | uint16 | uint32 | uint64](appendValue func(T)) appendFunc {
+	return func(value any) error {
+		v, ok := value.(int64)
+		if !ok {
+			return unexpectedType(value, "integer")
+		}
+		appendValue(T(v)) //nolint:gosec // Range is limited by the generator.
+		return nil
+	}
+}
+
+func appendFloat[T float32 | float64](appendValue func(T)) appendFunc {
+	return func(value any) error {
+		v, ok := value.(float64)
+		if !ok {
+			return unexpectedType(value, "float")
+		}
+		appendValue(T(v))
+		return nil
+	}
+}
+
+func appendTyped[T any](appendValue func(T)) appendFunc {
+	return func(value any) error {
+		v, ok := value.(T)
+		if !ok {
+			var zero T
+			return unexpectedType(value, zero)
+		}
+		appendValue(v)
+		return nil
+	}
+}
+
+func appendDecimal(builder *array.Decimal128Builder) appendFunc {
+	decimalType, _ := builder.Type().(*arrow.Decimal128Type)
+
+	return func(value any) error {
+		v, ok := value.(float64)
+		if !ok {
+			return unexpectedType(value, "decimal")
+		}
+
+		num, err := decimal128.FromFloat64(v, decimalType.Precision, decimalType.Scale)
+		if err != nil {
+			return err
+		}
+		builder.Append(num)
+
+		return nil
+	}
+}
+
+func appendTimestamp(builder *array.TimestampBuilder) appendFunc {
+	return func(value any) error {
+		var ts time.Time
+		switch v := value.(type) {
+		case time.Time:
+			ts = v
+		case string:
+			// Timestamps without time zone are generated as UTC strings
+			parsed, err := time.Parse(time.DateTime, v)
+			if err != nil {
+				return err
+			}
+			ts = parsed
+		default:
+			return unexpectedType(value, "timestamp")
+		}
+		builder.Append(arrow.Timestamp(ts.UnixMicro()))
+
+		return nil
+	}
+}
+
+func unexpectedType(value, target any) error {
+	return fmt.Errorf("cannot store %T value as %v", value, target)
+}
@@ -0,0 +1,351 @@
+// Package clone copies a sample of rows from a source database to a target one, masking sensitive columns
+// and keeping the foreign key closure of the sampled rows.
+package clone
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/dataloader"
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+)
+
+// keyChunkSize is the number of keys looked up per query when following foreign keys.
+const keyChunkSize = 500
+
+// Parser parses the structure of a table, like the mysql and postgres parsers do.
+type Parser func(dbConn any, schema, tableName string) (*domain.TableStructure, error)
+
+// Cloner copies rows between two databases of the same type.
+type Cloner struct {
+	Source    *sql.DB
+	Target    *sql.DB
+	DBType    string // "mysql" or "postgres"
+	Schema    string // Source database name, used to parse the tables
+	Parse     Parser
+	Masker    *Masker
+	BatchSize int
+	Parallel  int
+	Configure func(loader *dataloader.TableDataLoader) // Optional, applies the load settings to every table loader
+}
+
+// Result is the outcome of cloning the rows of a table.
+type Result struct {
+	Table   string
+	Summary dataloader.Summary
+}
+
+// table holds the rows copied from a source table.
+type table struct {
+	structure  *domain.TableStructure
+	primaryKey []string
+	columns    []string
+	rows       [][]any
+	seen       map[string]bool // Primary keys, or whole rows, of the copied rows
+	looked     map[string]bool // Foreign key values already looked up
+}
+
+// pendingRows are rows whose foreign keys have not been followed yet.
+type pendingRows struct {
+	table    *table
+	from, to int
+}
+
+// Clone copies up to numRows random rows of the table matching the where condition, if any, and the rows
+// they reference through foreign keys, recursively. Referenced tables are loaded first.
+func (c *Cloner) Clone(ctx context.Context, tableName, where string, numRows int) ([]Result, error) {
+	tables := make(map[string]*table)
+	root, err := c.table(tables, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	// The source is sampled without sorting it
+	query, err := dataloader.SampleQuery(ctx, c.Source, c.DBType, tableName, "*", where, numRows)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.fetch(ctx, root, query); err != nil {
+		return nil, err
+	}
+
+	// Follow the foreign keys until no new rows are referenced
+	queue := []pendingRows{{table: root, from: 0, to: len(root.rows)}}
+	for len(queue) > 0 {
+		pending := queue[0]
+		queue = queue[1:]
+
+		for _, fk := range pending.table.structure.ForeignKeys {
+			parent, tableErr := c.table(tables, fk.ReferencedTable)
+			if tableErr != nil {
+				return nil, tableErr
+			}
+
+			before := len(parent.rows)
+			if err = c.fetchReferenced(ctx, pending, fk, parent); err != nil {
+				return nil, err
+			}
+			if len(parent.rows) > before {
+				queue = append(queue, pendingRows{table: parent, from: before, to: len(parent.rows)})
+			}
+		}
+	}
+
+	order := loadOrder(tables, root, make(map[string]bool))
+	for _, t := range order {
+		if err = c.mask(t); err != nil {
+			return nil, err
+		}
+	}
+
+	var results []Result
+	for _, t := range order {
+		loader := dataloader.NewTableDataLoader(c.Target, c.DBType, t.structure, c.BatchSize, c.Parallel)
+		if c.Configure != nil {
+			c.Configure(loader)
+		}
+		log.Info().Int("rows", len(t.rows)).Msgf("Cloning %s", t.structure.Name)
+		err = loader.LoadRows(ctx, t.columns, t.rows)
+		results = append(results, Result{Table: t.structure.Name, Summary: loader.Stats()})
+		if err != nil {
+			return results, err
+		}
+		if err = c.advanceSequences(ctx, t); err != nil {
+			return results, err
+		}
+	}
+
+	return results, nil
+}
+
+// advanceSequences sets the Postgres sequences of the serial and identity columns of the table past the
+// copied values, so the rows inserted afterwards do not get the values of the copied ones. MySQL advances
+// the auto-increment counter on explicit values by itself.
+func (c *Cloner) advanceSequences(ctx context.Context, t *table) error {
+	if c.DBType != "postgres" {
+		return nil
+	}
+
+	for _, column := range t.columns {
+		var sequence sql.NullString
+		err := c.Target.QueryRowContext(ctx, "SELECT pg_get_serial_sequence($1, $2)", t.structure.Name, column).
+			Scan(&sequence)
+		if err != nil {
+			return fmt.Errorf("cannot read the sequence of %s.%s: %w", t.structure.Name, column, err)
+		}
+		if !sequence.Valid {
+			continue
+		}
+
+		query := fmt.Sprintf("SELECT setval($1, MAX(%s)) FROM %s HAVING MAX(%[1]s) IS NOT NULL",
+			column, t.structure.Name)
+		if _, err = c.Target.ExecContext(ctx, query, sequence.String); err != nil {
+			return fmt.Errorf("cannot advance the sequence %s: %w", sequence.String, err)
+		}
+	}
+
+	return nil
+}
+
+// table returns the rows copied from a table, parsing its structure the first time.
+func (c *Cloner) table(tables map[string]*table, name string) (*table, error) {
+	if t, ok := tables[name]; ok {
+		return t, nil
+	}
+
+	structure, err := c.Parse(c.Source, c.Schema, name)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse source table %s: %w", name, err)
+	}
+
+	t := &table{structure: structure, seen: make(map[string]bool), looked: make(map[string]bool)}
+	for _, index := range structure.Indexes {
+		if index.IsPrimary {
+			t.primaryKey = index.Columns
+		}
+	}
+	tables[name] = t
+
+	return t, nil
+}
+
+// fetchReferenced copies the rows of the parent table referenced by the pending rows through the foreign key.
+func (c *Cloner) fetchReferenced(ctx context.Context, pending pendingRows, fk domain.ForeignKey, parent *table) error {
+	indexes := columnIndexes(pending.table.columns, fk.Columns)
+
+	var keys [][]any
+	for _, row := range pending.table.rows[pending.from:pending.to] {
+		key := make([]any, len(indexes))
+		for i, index := range indexes {
+			key[i] = row[index]
+		}
+		id := fk.ReferencedTable + ":" + strings.Join(fk.ReferencedColumns, ",") + ":" + keyString(key)
+		if hasNull(key) || parent.looked[id] {
+			continue
+		}
+		parent.looked[id] = true
+		keys = append(keys, key)
+	}
+
+	for start := 0; start < len(keys); start += keyChunkSize {
+		chunk := keys[start:min(start+keyChunkSize, len(keys))]
+		query, args := c.lookupQuery(fk, chunk)
+		if err := c.fetch(ctx, parent, query, args...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// lookupQuery returns the query selecting the rows of the referenced table with the given keys.
+func (c *Cloner) lookupQuery(fk domain.ForeignKey, keys [][]any) (string, []any) {
+	tuple := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(fk.ReferencedColumns)), ", ") + ")"
+	tuples := make([]string, len(keys))
+	args := make([]any, 0, len(keys)*len(fk.ReferencedColumns))
+	for i, key := range keys {
+		tuples[i] = tuple
+		args = append(args, key...)
+	}
+
+	query := fmt.Sprintf("SELECT * FROM %s WHERE (%s) IN (%s)",
+		fk.ReferencedTable, strings.Join(fk.ReferencedColumns, ", "), strings.Join(tuples, ", "))
+	if c.DBType == "postgres" {
+		for i := 1; strings.Contains(query, "?"); i++ {
+			query = strings.Replace(query, "?", fmt.Sprintf("$%d", i), 1)
+		}
+	}
+
+	return query, args
+}
+
+// fetch runs the query and adds the rows not copied yet to the table.
+func (c *Cloner) fetch(ctx context.Context, t *table, query string, args ...any) error {
+	rows, err := c.Source.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", t.structure.Name, err)
+	}
+	defer rows.Close()
+
+	if t.columns == nil {
+		if t.columns, err = rows.Columns(); err != nil {
+			return fmt.Errorf("cannot read %s columns: %w", t.structure.Name, err)
+		}
+	}
+	keyIndexes := columnIndexes(t.columns, t.primaryKey)
+
+	for rows.Next() {
+		row := make([]any, len(t.columns))
+		pointers := make([]any, len(row))
+		for i := range row {
+			pointers[i] = &row[i]
+		}
+		if err = rows.Scan(pointers...); err != nil {
+			return fmt.Errorf("cannot read %s: %w", t.structure.Name, err)
+		}
+
+		key := row
+		if len(keyIndexes) > 0 {
+			key = make([]any, len(keyIndexes))
+			for i, index := range keyIndexes {
+				key[i] = row[index]
+			}
+		}
+		if id := keyString(key); !t.seen[id] {
+			t.seen[id] = true
+			t.rows = append(t.rows, row)
+		}
+	}
+
+	return rows.Err()
+}
+
+// mask masks the values of the masked columns of the table. Only text columns can be hashed.
+func (c *Cloner) mask(t *table) error {
+	if c.Masker == nil {
+		return nil
+	}
+
+	for i, column := range t.columns {
+		method := c.Masker.Method(t.structure.Name, column)
+		if method == "" {
+			continue
+		}
+
+		length, text := 0, false
+		for _, structColumn := range t.structure.Columns {
+			if structColumn.Name == column {
+				length, text = TextLength(structColumn.DataType)
+			}
+		}
+		if method == MaskHash && !text {
+			return fmt.Errorf("cannot hash %s.%s, only text columns can be hashed", t.structure.Name, column)
+		}
+
+		for _, row := range t.rows {
+			row[i] = c.Masker.Mask(method, row[i], length)
+		}
+	}
+
+	return nil
+}
+
+// loadOrder returns the tables referenced by t, recursively, followed by t.
+// Self references are ignored, so they are only satisfied if the rows happen to be loaded in order.
+func loadOrder(tables map[string]*table, t *table, visited map[string]bool) []*table {
+	visited[t.structure.Name] = true
+
+	var order []*table
+	for _, fk := range t.structure.ForeignKeys {
+		parent, ok := tables[fk.ReferencedTable]
+		if !ok || visited[fk.ReferencedTable] {
+			continue
+		}
+		order = append(order, loadOrder(tables, parent, visited)...)
+	}
+
+	return append(order, t)
+}
+
+// columnIndexes returns the positions of the columns in the list of all the columns.
+func columnIndexes(all, columns []string) []int {
+	indexes := make([]int, 0, len(columns))
+	for _, column := range columns {
+		for i, name := range all {
+			if name == column {
+				indexes = append(indexes, i)
+				break
+			}
+		}
+	}
+
+	return indexes
+}
+
+// keyString returns a comparable representation of the key values. Text values may be returned as
+// strings or bytes depending on the driver protocol, so both are represented the same way.
+func keyString(key []any) string {
+	parts := make([]string, len(key))
+	for i, value := range key {
+		if b, ok := value.([]byte); ok {
+			value = string(b)
+		}
+		parts[i] = fmt.Sprint(value)
+	}
+
+	return strings.Join(parts, "\x00")
+}
+
+func hasNull(key []any) bool {
+	for _, value := range key {
+		if value == nil {
+			return true
+		}
+	}
+
+	return false
+}
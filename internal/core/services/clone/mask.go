@@ -0,0 +1,137 @@
+package clone
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Masking methods.
+const (
+	MaskHash   = "hash"   // Keyed hash, as long as the original but at least minHashLength, within the column length
+	MaskEmail  = "email"  // Hashed local part at example.com
+	MaskFormat = "format" // Random letters and digits in place of the original ones, keeping the rest
+	MaskName   = "name"   // Like format, capitalizing every word
+	MaskPhone  = "phone"  // Random digits in place of the original ones, keeping the rest
+	MaskNull   = "null"   // NULL
+)
+
+// minHashLength is the minimum length of the hashes, so short distinct values do not collide.
+const minHashLength = 16
+
+// Masker replaces sensitive values. Masking is deterministic for a given key, so equal values are masked
+// equally in every table and masked keys still join.
+type Masker struct {
+	Key     []byte
+	Methods map[string]string // Masking method by column name or table.column
+}
+
+// NewMasker creates a masker from a specification like email:email,customers.name:name.
+func NewMasker(key []byte, spec string) (*Masker, error) {
+	m := &Masker{Key: key, Methods: make(map[string]string)}
+	if spec == "" {
+		return m, nil
+	}
+
+	for _, part := range strings.Split(spec, ",") {
+		column, method, found := strings.Cut(strings.TrimSpace(part), ":")
+		if !found {
+			return nil, fmt.Errorf("invalid mask %q, expected column:method", part)
+		}
+		switch method {
+		case MaskHash, MaskEmail, MaskFormat, MaskName, MaskPhone, MaskNull:
+		default:
+			return nil, fmt.Errorf("unknown masking method %q", method)
+		}
+		m.Methods[column] = method
+	}
+
+	return m, nil
+}
+
+// Method returns the masking method of a column, or an empty string if the column is not masked.
+func (m *Masker) Method(table, column string) string {
+	if method, ok := m.Methods[table+"."+column]; ok {
+		return method
+	}
+
+	return m.Methods[column]
+}
+
+// Mask masks a value with the given method. NULL values are not masked. The length is the declared length of
+// the text column, 0 if unbounded, which hashes do not exceed.
+func (m *Masker) Mask(method string, value any, length int) any {
+	if value == nil || method == "" {
+		return value
+	}
+	if method == MaskNull {
+		return nil
+	}
+
+	var text string
+	switch v := value.(type) {
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	default:
+		text = fmt.Sprint(v)
+	}
+
+	mac := hmac.New(sha256.New, m.Key)
+	mac.Write([]byte(text))
+	sum := mac.Sum(nil)
+
+	switch method {
+	case MaskHash:
+		hash := hex.EncodeToString(sum)
+		size := min(max(utf8.RuneCountInString(text), minHashLength), len(hash))
+		if length > 0 {
+			size = min(size, length)
+		}
+		return hash[:size]
+	case MaskEmail:
+		return hex.EncodeToString(sum[:6]) + "@example.com"
+	}
+
+	r := rand.New(rand.NewPCG(binary.BigEndian.Uint64(sum[:8]), binary.BigEndian.Uint64(sum[8:16])))
+	masked := []rune(text)
+	for i, c := range masked {
+		switch {
+		case unicode.IsDigit(c):
+			masked[i] = rune('0' + r.IntN(10))
+		case method == MaskPhone, !unicode.IsLetter(c):
+		case unicode.IsUpper(c), method == MaskName && (i == 0 || unicode.IsSpace(masked[i-1])):
+			masked[i] = rune('A' + r.IntN(26))
+		default:
+			masked[i] = rune('a' + r.IntN(26))
+		}
+	}
+
+	return string(masked)
+}
+
+// TextLength returns the declared length of a text data type, like 20 for varchar(20), or 0 if unbounded.
+// The result is false if the type is not text.
+func TextLength(dataType string) (int, bool) {
+	base, args, _ := strings.Cut(strings.ToLower(dataType), "(")
+	base = strings.TrimSpace(base)
+	if !strings.Contains(base, "char") && !strings.Contains(base, "text") {
+		return 0, false
+	}
+
+	args, _, _ = strings.Cut(args, ")")
+	length, err := strconv.Atoi(strings.TrimSpace(args))
+	if err != nil {
+		return 0, true
+	}
+
+	return length, true
+}
@@ -0,0 +1,74 @@
+package clone_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/services/clone"
+)
+
+func TestNewMasker(t *testing.T) {
+	masker, err := clone.NewMasker([]byte("key"), "email:email, customers.name:name")
+	require.NoError(t, err)
+	assert.Equal(t, clone.MaskEmail, masker.Method("orders", "email"))
+	assert.Equal(t, clone.MaskName, masker.Method("customers", "name"))
+	assert.Empty(t, masker.Method("orders", "name"))
+
+	_, err = clone.NewMasker(nil, "email")
+	require.Error(t, err)
+	_, err = clone.NewMasker(nil, "email:rot13")
+	require.Error(t, err)
+}
+
+func TestMask(t *testing.T) {
+	masker := &clone.Masker{Key: []byte("key")}
+
+	email := masker.Mask(clone.MaskEmail, "john.doe@acme.com", 0)
+	assert.Regexp(t, `^[0-9a-f]{12}@example\.com$`, email)
+	assert.Equal(t, email, masker.Mask(clone.MaskEmail, []byte("john.doe@acme.com"), 0), "masking is deterministic")
+	assert.NotEqual(t, email, (&clone.Masker{Key: []byte("other")}).Mask(clone.MaskEmail, "john.doe@acme.com", 0))
+
+	name, ok := masker.Mask(clone.MaskName, "john o'neil", 0).(string)
+	require.True(t, ok)
+	assert.Regexp(t, `^[A-Z][a-z]{3} [A-Z]'[a-z]{4}$`, name)
+
+	assert.Regexp(t, `^\+\d \(\d{3}\) \d{3}-\d{4}$`, masker.Mask(clone.MaskPhone, "+1 (555) 123-4567", 0))
+	assert.Regexp(t, `^[A-Z]{2}-\d{4}$`, masker.Mask(clone.MaskFormat, "AB-1234", 0))
+	assert.Len(t, masker.Mask(clone.MaskHash, "a long secret value", 0), 19)
+	assert.Len(t, masker.Mask(clone.MaskHash, "ab", 0), 16)
+	assert.Len(t, masker.Mask(clone.MaskHash, "ab", 10), 10, "hashes fit the column")
+	assert.Len(t, masker.Mask(clone.MaskHash, "ñandú", 0), 16)
+	assert.Nil(t, masker.Mask(clone.MaskNull, "value", 0))
+	assert.Nil(t, masker.Mask(clone.MaskHash, nil, 0))
+}
+
+func TestMaskHashCollisions(t *testing.T) {
+	masker := &clone.Masker{Key: []byte("key")}
+
+	// Every two character printable ASCII value
+	seen := make(map[any]string)
+	for a := byte(' '); a <= '~'; a++ {
+		for b := byte(' '); b <= '~'; b++ {
+			value := string([]byte{a, b})
+			hash := masker.Mask(clone.MaskHash, value, 0)
+			if previous, ok := seen[hash]; ok {
+				t.Fatalf("%q and %q have the same hash", previous, value)
+			}
+			seen[hash] = value
+		}
+	}
+}
+
+func TestTextLength(t *testing.T) {
+	for dataType, expected := range map[string]int{"varchar(20)": 20, "character varying(8)": 8, "text": 0, "char": 0} {
+		length, ok := clone.TextLength(dataType)
+		assert.True(t, ok, dataType)
+		assert.Equal(t, expected, length, dataType)
+	}
+	for _, dataType := range []string{"int", "uuid", "timestamp", "json"} {
+		_, ok := clone.TextLength(dataType)
+		assert.False(t, ok, dataType)
+	}
+}
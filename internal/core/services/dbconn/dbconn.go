@@ -0,0 +1,181 @@
+// Package dbconn opens the database connections with the TLS, timeout, session and pool options, which are
+// applied on top of the settings of the DSN.
+package dbconn
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/services/dsn"
+)
+
+// TLS modes. An empty mode keeps the one of the DSN, or the driver default.
+const (
+	TLSDisable    = "disable"
+	TLSRequire    = "require"
+	TLSVerifyCA   = "verify-ca"
+	TLSVerifyFull = "verify-full"
+)
+
+// Options are the connection options not covered by the DSN settings. Zero values keep the DSN settings
+// or the driver defaults.
+type Options struct {
+	TLSMode    string
+	CACert     string
+	ClientCert string
+	ClientKey  string
+
+	ConnectTimeout time.Duration
+	// InitStatements run on every new connection, e.g. SET time_zone = '+00:00'.
+	InitStatements []string
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// Open connects to the database and checks the connection.
+func Open(ctx context.Context, dbType, dataSource string, opts Options) (*sql.DB, error) {
+	var connector driver.Connector
+	switch dbType {
+	case "mysql":
+		cfg, err := MySQLConfig(dataSource, opts)
+		if err != nil {
+			return nil, err
+		}
+		if connector, err = mysql.NewConnector(cfg); err != nil {
+			return nil, err
+		}
+	case "postgres":
+		dataSource, err := PostgresDSN(dataSource, opts)
+		if err != nil {
+			return nil, err
+		}
+		if connector, err = pq.NewConnector(dataSource); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported database type: %s", dbType)
+	}
+
+	if len(opts.InitStatements) > 0 {
+		connector = &initConnector{Connector: connector, statements: opts.InitStatements}
+	}
+
+	db := sql.OpenDB(connector)
+	db.SetMaxOpenConns(opts.MaxOpenConns)
+	db.SetMaxIdleConns(opts.MaxIdleConns)
+	db.SetConnMaxLifetime(opts.ConnMaxLifetime)
+
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// MySQLConfig returns the driver configuration of the DSN with the options applied. The TLS configuration
+// is registered in the driver with the name random_data_loader.
+func MySQLConfig(dataSource string, opts Options) (*mysql.Config, error) {
+	cfg, err := mysql.ParseDSN(dataSource)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MySQL DSN: %w", err)
+	}
+
+	if opts.ConnectTimeout > 0 {
+		cfg.Timeout = opts.ConnectTimeout
+	}
+
+	switch opts.TLSMode {
+	case "":
+		if opts.CACert != "" || opts.ClientCert != "" {
+			return nil, fmt.Errorf("the TLS certificates need a TLS mode: %s, %s or %s",
+				TLSRequire, TLSVerifyCA, TLSVerifyFull)
+		}
+	case TLSDisable:
+		cfg.TLS, cfg.TLSConfig = nil, "false"
+	default:
+		tlsConfig, err := newTLSConfig(opts)
+		if err != nil {
+			return nil, err
+		}
+		if err = mysql.RegisterTLSConfig(tlsConfigName, tlsConfig); err != nil {
+			return nil, err
+		}
+		cfg.TLS, cfg.TLSConfig = nil, tlsConfigName
+	}
+
+	return cfg, nil
+}
+
+// PostgresDSN returns the DSN, URL or key/value, as a key/value connection string with the options
+// appended. Later settings take precedence in lib/pq, so the options override the ones of the DSN.
+func PostgresDSN(dataSource string, opts Options) (string, error) {
+	if strings.HasPrefix(dataSource, "postgres://") || strings.HasPrefix(dataSource, "postgresql://") {
+		var err error
+		if dataSource, err = pq.ParseURL(dataSource); err != nil {
+			return "", fmt.Errorf("invalid Postgres DSN: %w", err)
+		}
+	}
+
+	settings := []string{dataSource}
+	add := func(key, value string) {
+		if value != "" {
+			settings = append(settings, key+"="+dsn.QuotePostgres(value))
+		}
+	}
+
+	switch opts.TLSMode {
+	case "", TLSDisable, TLSRequire, TLSVerifyCA, TLSVerifyFull:
+		add("sslmode", opts.TLSMode)
+	default:
+		return "", fmt.Errorf("unsupported TLS mode: %s", opts.TLSMode)
+	}
+	add("sslrootcert", opts.CACert)
+	add("sslcert", opts.ClientCert)
+	add("sslkey", opts.ClientKey)
+	if opts.ConnectTimeout > 0 {
+		// The timeout is in whole seconds, rounded up so short timeouts are not disabled
+		add("connect_timeout", strconv.Itoa(int(math.Ceil(opts.ConnectTimeout.Seconds()))))
+	}
+
+	return strings.TrimSpace(strings.Join(settings, " ")), nil
+}
+
+// initConnector runs the init statements on the new connections.
+type initConnector struct {
+	driver.Connector
+	statements []string
+}
+
+// Connect returns a new connection, after running the init statements on it.
+func (c *initConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		_ = conn.Close()
+		return nil, fmt.Errorf("the %T driver cannot run init statements", c.Driver())
+	}
+	for _, statement := range c.statements {
+		if _, err = execer.ExecContext(ctx, statement, nil); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("init statement %q failed: %w", statement, err)
+		}
+	}
+
+	return conn, nil
+}
@@ -0,0 +1,96 @@
+package dbconn_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/services/dbconn"
+)
+
+func TestMySQLConfig(t *testing.T) {
+	cfg, err := dbconn.MySQLConfig("root:root@tcp(db:3306)/shop?tls=true", dbconn.Options{
+		TLSMode:        dbconn.TLSDisable,
+		ConnectTimeout: 3 * time.Second,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "false", cfg.TLSConfig)
+	assert.Equal(t, 3*time.Second, cfg.Timeout)
+
+	cfg, err = dbconn.MySQLConfig("root@tcp(db:3306)/shop", dbconn.Options{
+		TLSMode: dbconn.TLSVerifyFull,
+		CACert:  writeCACert(t),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "random_data_loader", cfg.TLSConfig)
+
+	_, err = dbconn.MySQLConfig("root@tcp(db:3306)/shop", dbconn.Options{
+		TLSMode: dbconn.TLSVerifyCA,
+		CACert:  filepath.Join(t.TempDir(), "missing.pem"),
+	})
+	require.Error(t, err)
+
+	_, err = dbconn.MySQLConfig("root@tcp(db:3306)/shop", dbconn.Options{CACert: writeCACert(t)})
+	require.Error(t, err, "certificates without a TLS mode")
+
+	_, err = dbconn.MySQLConfig("root@tcp(db:3306)/shop", dbconn.Options{TLSMode: "sometimes"})
+	require.Error(t, err)
+}
+
+func TestPostgresDSN(t *testing.T) {
+	built, err := dbconn.PostgresDSN("postgres://app:secret@db:5433/shop?sslmode=disable", dbconn.Options{
+		TLSMode:        dbconn.TLSVerifyFull,
+		CACert:         "/etc/ssl/ca.pem",
+		ConnectTimeout: 1500 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	assert.Contains(t, built, "sslmode='disable'")
+	assert.Contains(t, built, "host='db'")
+	assert.Contains(t, built, "sslmode='verify-full' sslrootcert='/etc/ssl/ca.pem' connect_timeout='2'")
+
+	built, err = dbconn.PostgresDSN("", dbconn.Options{TLSMode: dbconn.TLSRequire})
+	require.NoError(t, err)
+	assert.Equal(t, "sslmode='require'", built)
+
+	built, err = dbconn.PostgresDSN("host=db dbname=shop", dbconn.Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "host=db dbname=shop", built)
+
+	_, err = dbconn.PostgresDSN("", dbconn.Options{TLSMode: "prefer"})
+	require.Error(t, err)
+}
+
+// writeCACert writes a self-signed CA certificate and returns its path.
+func writeCACert(t *testing.T) string {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+
+	return path
+}
@@ -0,0 +1,79 @@
+package dbconn
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// tlsConfigName is the name of the TLS configuration registered in the MySQL driver.
+const tlsConfigName = "random_data_loader"
+
+// newTLSConfig returns the MySQL TLS configuration of the mode, following the Postgres sslmode semantics:
+// require encrypts without checking the server certificate, verify-ca checks it was signed by the CA and
+// verify-full also checks the host name. The system CAs are used if no CA certificate is given.
+func newTLSConfig(opts Options) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if opts.CACert != "" {
+		pem, err := os.ReadFile(opts.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read the CA certificate: %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates in %s", opts.CACert)
+		}
+	}
+
+	if opts.ClientCert != "" || opts.ClientKey != "" {
+		cert, err := tls.LoadX509KeyPair(opts.ClientCert, opts.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load the client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	switch opts.TLSMode {
+	case TLSRequire:
+		config.InsecureSkipVerify = true //nolint:gosec // The server certificate is not checked in require mode.
+	case TLSVerifyCA:
+		// The chain is checked by verifyChain, without the host name check of the default verification
+		config.InsecureSkipVerify = true //nolint:gosec // Replaced by verifyChain.
+		config.VerifyPeerCertificate = verifyChain(config.RootCAs)
+	case TLSVerifyFull:
+		// The driver sets the server name to the host of the DSN
+	default:
+		return nil, fmt.Errorf("unsupported TLS mode: %s", opts.TLSMode)
+	}
+
+	return config, nil
+}
+
+// verifyChain returns a function checking the server certificate was signed by the roots.
+func verifyChain(roots *x509.CertPool) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("the server sent no certificate")
+		}
+
+		certs := make([]*x509.Certificate, len(rawCerts))
+		for i, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return err
+			}
+			certs[i] = cert
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+		_, err := certs[0].Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates})
+
+		return err
+	}
+}
@@ -0,0 +1,69 @@
+package ddl
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+)
+
+// TableExists reports whether the table exists in the current database or search path.
+func TableExists(ctx context.Context, db *sql.DB, dbType, table string) (bool, error) {
+	var exists bool
+	var err error
+	switch dbType {
+	case "mysql":
+		err = db.QueryRowContext(ctx, `
+			SELECT COUNT(*) > 0
+			FROM INFORMATION_SCHEMA.TABLES
+			WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?`, table).Scan(&exists)
+	case "postgres":
+		err = db.QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL", quoter(dbType).table(table)).Scan(&exists)
+	default:
+		return false, fmt.Errorf("unsupported database type: %s", dbType)
+	}
+	if err != nil {
+		return false, fmt.Errorf("cannot check whether %s exists: %w", table, err)
+	}
+
+	return exists, nil
+}
+
+// Create creates the table with its indexes and foreign keys. The foreign keys referencing missing tables are
+// left out with a warning, so a table can be created before the tables it references.
+func Create(ctx context.Context, db *sql.DB, dbType string, table *domain.TableStructure) error {
+	statements, err := Render(table, dbType)
+	if err != nil {
+		return err
+	}
+
+	for _, statement := range append([]string{statements.CreateTable}, statements.Indexes...) {
+		log.Debug().Msg(statement)
+		if _, err = db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("cannot create %s: %w", table.Name, err)
+		}
+	}
+
+	for i, fk := range table.ForeignKeys {
+		exists := fk.ReferencedTable == table.Name
+		if !exists {
+			if exists, err = TableExists(ctx, db, dbType, fk.ReferencedTable); err != nil {
+				return err
+			}
+		}
+		if !exists {
+			log.Warn().Msgf("Foreign key %s is not created, table %s does not exist", fk.Name, fk.ReferencedTable)
+			continue
+		}
+
+		log.Debug().Msg(statements.ForeignKeys[i])
+		if _, err = db.ExecContext(ctx, statements.ForeignKeys[i]); err != nil {
+			return fmt.Errorf("cannot create foreign key %s: %w", fk.Name, err)
+		}
+	}
+
+	return nil
+}
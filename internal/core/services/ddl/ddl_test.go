@@ -0,0 +1,180 @@
+package ddl_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+	"github.com/cfsalguero/random_data_loader/internal/core/services/ddl"
+)
+
+func testTable() *domain.TableStructure {
+	return &domain.TableStructure{
+		Name: "orders",
+		Columns: []domain.TableColumn{
+			{Name: "id", DataType: "int unsigned"},
+			{Name: "customer_id", DataType: "int"},
+			{Name: "amount", DataType: "decimal(10,2)", Nullable: true, Precision: 10, Scale: 2},
+			{Name: "status", DataType: "enum('new','paid')", Default: "'new'"},
+			{Name: "note", DataType: "text", Nullable: true},
+			{Name: "created_at", DataType: "datetime(3)", Default: "CURRENT_TIMESTAMP(3)"},
+		},
+		Indexes: []domain.TableIndex{
+			{Name: "PRIMARY", Columns: []string{"id"}, IsPrimary: true, IsUnique: true},
+			{Name: "idx_status", Columns: []string{"status", "created_at"}},
+			{Name: "uq_note", Columns: []string{"note"}, IsUnique: true, Prefixes: []int{100}},
+		},
+		ForeignKeys: []domain.ForeignKey{
+			{Name: "fk_customer", Columns: []string{"customer_id"}, ReferencedTable: "customers", ReferencedColumns: []string{"id"}},
+		},
+	}
+}
+
+func TestRenderMySQL(t *testing.T) {
+	statements, err := ddl.Render(testTable(), "mysql")
+	require.NoError(t, err)
+
+	assert.Equal(t, "CREATE TABLE `orders` (\n"+
+		"  `id` int unsigned NOT NULL AUTO_INCREMENT,\n"+
+		"  `customer_id` int NOT NULL,\n"+
+		"  `amount` decimal(10,2),\n"+
+		"  `status` enum('new','paid') NOT NULL DEFAULT 'new',\n"+
+		"  `note` text,\n"+
+		"  `created_at` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),\n"+
+		"  PRIMARY KEY (`id`)\n"+
+		")", statements.CreateTable)
+	assert.Equal(t, []string{
+		"CREATE INDEX `idx_status` ON `orders` (`status`, `created_at`)",
+		"CREATE UNIQUE INDEX `uq_note` ON `orders` (`note`(100))",
+	}, statements.Indexes)
+	assert.Equal(t, []string{
+		"ALTER TABLE `orders` ADD CONSTRAINT `fk_customer` FOREIGN KEY (`customer_id`) REFERENCES `customers` (`id`)",
+	}, statements.ForeignKeys)
+	assert.Len(t, statements.All(), 4)
+
+	// Text columns cannot be indexed whole
+	table := testTable()
+	table.Indexes[2].Prefixes = nil
+	statements, err = ddl.Render(table, "mysql")
+	require.NoError(t, err)
+	assert.Equal(t, "CREATE UNIQUE INDEX `uq_note` ON `orders` (`note`(255))", statements.Indexes[1])
+}
+
+func TestRenderPostgres(t *testing.T) {
+	statements, err := ddl.Render(testTable(), "postgres")
+	require.NoError(t, err)
+
+	assert.Equal(t, `CREATE TABLE "orders" (`+"\n"+
+		`  "id" bigint NOT NULL GENERATED BY DEFAULT AS IDENTITY,`+"\n"+
+		`  "customer_id" integer NOT NULL,`+"\n"+
+		`  "amount" numeric(10,2),`+"\n"+
+		`  "status" text NOT NULL DEFAULT 'new',`+"\n"+
+		`  "note" text,`+"\n"+
+		`  "created_at" timestamp(3) NOT NULL DEFAULT CURRENT_TIMESTAMP,`+"\n"+
+		`  PRIMARY KEY ("id")`+"\n"+
+		`)`, statements.CreateTable)
+	assert.Equal(t, `CREATE INDEX "idx_status" ON "orders" ("status", "created_at")`, statements.Indexes[0])
+	assert.Equal(t, `CREATE UNIQUE INDEX "uq_note" ON "orders" ("note")`, statements.Indexes[1])
+
+	_, err = ddl.Render(testTable(), "oracle")
+	require.Error(t, err)
+}
+
+func TestParseMySQL(t *testing.T) {
+	tables, err := ddl.Parse("-- Dump of shop\n" +
+		"SET NAMES utf8mb4;\n" +
+		"CREATE TABLE `shop`.`orders` (\n" +
+		"  `id` int unsigned NOT NULL AUTO_INCREMENT,\n" +
+		"  `customer_id` int NOT NULL,\n" +
+		"  `amount` decimal(10,2) DEFAULT NULL,\n" +
+		"  `status` enum('new','paid') NOT NULL DEFAULT 'new',\n" +
+		"  `note` text CHARACTER SET utf8mb4 COLLATE utf8mb4_bin, /* free text */\n" +
+		"  `created_at` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  KEY `idx_status` (`status`,`created_at`),\n" +
+		"  UNIQUE KEY `uq_note` (`note`(100)),\n" +
+		"  CONSTRAINT `fk_customer` FOREIGN KEY (`customer_id`) REFERENCES `customers` (`id`) ON DELETE CASCADE\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;\n")
+	require.NoError(t, err)
+	require.Len(t, tables, 1)
+
+	expected := testTable()
+	expected.Columns[2].Default = "NULL"
+	assert.Equal(t, expected, tables[0])
+}
+
+func TestParsePostgres(t *testing.T) {
+	tables, err := ddl.Parse(`
+		CREATE TABLE IF NOT EXISTS public.customers (
+			id bigserial PRIMARY KEY,
+			email character varying(100) NOT NULL UNIQUE,
+			balance numeric(12,2) DEFAULT 0.00,
+			tags text[],
+			joined timestamp(0) with time zone DEFAULT now()
+		);
+		CREATE TABLE orders (
+			id serial,
+			customer_id bigint REFERENCES customers (id),
+			status varchar(10) DEFAULT 'new'::character varying,
+			CONSTRAINT orders_pkey PRIMARY KEY (id)
+		);
+		CREATE UNIQUE INDEX CONCURRENTLY orders_status_idx ON ONLY public.orders USING btree (status, id DESC);
+		ALTER TABLE ONLY orders ADD CONSTRAINT orders_self_fkey FOREIGN KEY (customer_id) REFERENCES orders(id);
+	`)
+	require.NoError(t, err)
+	require.Len(t, tables, 2)
+
+	customers := tables[0]
+	assert.Equal(t, "customers", customers.Name)
+	assert.Equal(t, []domain.TableColumn{
+		{Name: "id", DataType: "bigint"},
+		{Name: "email", DataType: "character varying(100)"},
+		{Name: "balance", DataType: "numeric(12,2)", Nullable: true, Default: "0.00", Precision: 12, Scale: 2},
+		{Name: "tags", DataType: "array", Nullable: true},
+		{Name: "joined", DataType: "timestamp(0) with time zone", Nullable: true, Default: "now()"},
+	}, customers.Columns)
+	assert.Equal(t, []domain.TableIndex{
+		{Name: "PRIMARY", Columns: []string{"id"}, IsUnique: true, IsPrimary: true},
+		{Name: "customers_email_key", Columns: []string{"email"}, IsUnique: true},
+	}, customers.Indexes)
+
+	orders := tables[1]
+	assert.Equal(t, "'new'::character varying", orders.Columns[2].Default)
+	assert.Equal(t, []domain.TableIndex{
+		{Name: "PRIMARY", Columns: []string{"id"}, IsUnique: true, IsPrimary: true},
+		{Name: "orders_status_idx", Columns: []string{"status", "id"}, IsUnique: true},
+	}, orders.Indexes)
+	assert.Equal(t, []domain.ForeignKey{
+		{Name: "orders_customer_id_fkey", Columns: []string{"customer_id"}, ReferencedTable: "customers", ReferencedColumns: []string{"id"}},
+		{Name: "orders_self_fkey", Columns: []string{"customer_id"}, ReferencedTable: "orders", ReferencedColumns: []string{"id"}},
+	}, orders.ForeignKeys)
+
+	// Translated to MySQL
+	statements, err := ddl.Render(customers, "mysql")
+	require.NoError(t, err)
+	assert.Equal(t, "CREATE TABLE `customers` (\n"+
+		"  `id` bigint NOT NULL AUTO_INCREMENT,\n"+
+		"  `email` varchar(100) NOT NULL,\n"+
+		"  `balance` decimal(12,2) DEFAULT 0.00,\n"+
+		"  `tags` json,\n"+
+		"  `joined` datetime(0) DEFAULT CURRENT_TIMESTAMP(0),\n"+
+		"  PRIMARY KEY (`id`)\n"+
+		")", statements.CreateTable)
+}
+
+func TestParseErrors(t *testing.T) {
+	for name, data := range map[string]string{
+		"no table":           "CREATE INDEX idx ON orders (id);",
+		"no columns":         "CREATE TABLE orders ();",
+		"unbalanced":         "CREATE TABLE orders (id int",
+		"unterminated":       "CREATE TABLE orders (status varchar(10) DEFAULT 'new);",
+		"missing references": "CREATE TABLE orders (customer_id int REFERENCES customers);",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ddl.Parse(data)
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,678 @@
+package ddl
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+)
+
+// token is a lexical token of a DDL file. Quoted identifiers are unquoted, string literals keep their quotes.
+type token struct {
+	text   string
+	quoted bool // Quoted identifier, never a keyword
+}
+
+// is reports whether the token is one of the keywords, case insensitively.
+func (t token) is(keywords ...string) bool {
+	if t.quoted {
+		return false
+	}
+	for _, keyword := range keywords {
+		if strings.EqualFold(t.text, keyword) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Parse parses the CREATE TABLE statements of a MySQL or Postgres DDL file, with the CREATE INDEX and
+// ALTER TABLE ... ADD statements on them. Other statements are ignored.
+func Parse(data string) ([]*domain.TableStructure, error) {
+	tokens, err := tokenize(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []*domain.TableStructure
+	find := func(name string) *domain.TableStructure {
+		for _, table := range tables {
+			if table.Name == name {
+				return table
+			}
+		}
+		return nil
+	}
+
+	for _, statement := range splitStatements(tokens) {
+		p := &parser{tokens: statement}
+		switch {
+		case p.accept("CREATE") && p.acceptSkipping("TABLE", "TEMPORARY", "UNLOGGED"):
+			table, err := p.createTable()
+			if err != nil {
+				return nil, err
+			}
+			tables = append(tables, table)
+		case p.pos == 1 && p.acceptSkipping("INDEX", "UNIQUE"):
+			index, tableName, err := p.createIndex()
+			if err != nil {
+				return nil, err
+			}
+			if table := find(tableName); table != nil {
+				table.Indexes = append(table.Indexes, index)
+			}
+		case p.accept("ALTER") && p.accept("TABLE"):
+			p.accept("ONLY")
+			table := find(p.name())
+			if table == nil {
+				continue
+			}
+			for p.accept("ADD") {
+				if err := p.tableElement(table); err != nil {
+					return nil, err
+				}
+				p.skipElement()
+			}
+		}
+	}
+
+	if len(tables) == 0 {
+		return nil, errors.New("no CREATE TABLE statement found")
+	}
+
+	return tables, nil
+}
+
+// tokenize splits the DDL in tokens, skipping the comments.
+//
+//nolint:cyclop // A single pass lexer.
+func tokenize(data string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.HasPrefix(data[i:], "--") || c == '#':
+			end := strings.IndexByte(data[i:], '\n')
+			if end == -1 {
+				return tokens, nil
+			}
+			i += end + 1
+		case strings.HasPrefix(data[i:], "/*"):
+			end := strings.Index(data[i+2:], "*/")
+			if end == -1 {
+				return nil, errors.New("unterminated comment")
+			}
+			i += end + 4
+		case c == '\'':
+			end := i + 1
+			for ; end < len(data); end++ {
+				if data[end] == '\\' {
+					end++
+				} else if data[end] == '\'' {
+					if end+1 < len(data) && data[end+1] == '\'' {
+						end++
+						continue
+					}
+					break
+				}
+			}
+			if end >= len(data) {
+				return nil, errors.New("unterminated string literal")
+			}
+			tokens = append(tokens, token{text: data[i : end+1]})
+			i = end + 1
+		case c == '`' || c == '"':
+			end := i + 1
+			var text strings.Builder
+			for ; end < len(data); end++ {
+				if data[end] == c {
+					if end+1 < len(data) && data[end+1] == c {
+						text.WriteByte(c)
+						end++
+						continue
+					}
+					break
+				}
+				text.WriteByte(data[end])
+			}
+			if end >= len(data) {
+				return nil, errors.New("unterminated quoted identifier")
+			}
+			tokens = append(tokens, token{text: text.String(), quoted: true})
+			i = end + 1
+		case isWordByte(c):
+			end := i
+			for end < len(data) && isWordByte(data[end]) {
+				end++
+			}
+			tokens = append(tokens, token{text: data[i:end]})
+			i = end
+		case strings.HasPrefix(data[i:], "::"):
+			tokens = append(tokens, token{text: "::"})
+			i += 2
+		default:
+			tokens = append(tokens, token{text: string(c)})
+			i++
+		}
+	}
+
+	return tokens, nil
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// splitStatements splits the tokens on the semicolons.
+func splitStatements(tokens []token) [][]token {
+	var statements [][]token
+	start := 0
+	for i, t := range tokens {
+		if t.text == ";" && !t.quoted {
+			if i > start {
+				statements = append(statements, tokens[start:i])
+			}
+			start = i + 1
+		}
+	}
+	if start < len(tokens) {
+		statements = append(statements, tokens[start:])
+	}
+
+	return statements
+}
+
+// parser reads the tokens of a statement.
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *parser) peek() token {
+	if p.done() {
+		return token{}
+	}
+
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.peek()
+	p.pos++
+
+	return t
+}
+
+// accept consumes the next token if it is the keyword or punctuation.
+func (p *parser) accept(keyword string) bool {
+	if t := p.peek(); t.is(keyword) {
+		p.pos++
+		return true
+	}
+
+	return false
+}
+
+// acceptSkipping consumes the optional modifiers and then the keyword, if it follows them.
+func (p *parser) acceptSkipping(keyword string, modifiers ...string) bool {
+	start := p.pos
+	for p.peek().is(modifiers...) {
+		p.pos++
+	}
+	if p.accept(keyword) {
+		return true
+	}
+	p.pos = start
+
+	return false
+}
+
+// name reads a possibly qualified name, dropping the schema, as the tables are loaded in --database.
+func (p *parser) name() string {
+	name := p.next().text
+	for p.accept(".") {
+		name = p.next().text
+	}
+
+	return name
+}
+
+// group returns the tokens between the parentheses starting at the current token, consuming them.
+func (p *parser) group() ([]token, error) {
+	if !p.accept("(") {
+		return nil, fmt.Errorf("expected ( but found %q", p.peek().text)
+	}
+
+	start := p.pos
+	for depth := 1; !p.done(); p.pos++ {
+		switch p.peek().text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		}
+		if depth == 0 && !p.peek().quoted {
+			p.pos++
+			return p.tokens[start : p.pos-1], nil
+		}
+	}
+
+	return nil, errors.New("unbalanced parentheses")
+}
+
+// columnList reads a list of columns between parentheses, ignoring the sort orders.
+func (p *parser) columnList() ([]string, error) {
+	tokens, err := p.group()
+	if err != nil {
+		return nil, err
+	}
+
+	var columns []string
+	for _, element := range splitElements(tokens) {
+		if len(element) > 0 {
+			columns = append(columns, element[0].text)
+		}
+	}
+
+	return columns, nil
+}
+
+// skipElement skips the rest of a comma separated element, like the ON DELETE clause of a foreign key, and
+// the comma.
+func (p *parser) skipElement() {
+	for !p.done() {
+		if p.peek().text == "(" {
+			if _, err := p.group(); err != nil {
+				return
+			}
+			continue
+		}
+		if p.next().text == "," {
+			return
+		}
+	}
+}
+
+// splitElements splits the tokens on the commas outside parentheses.
+func splitElements(tokens []token) [][]token {
+	var elements [][]token
+	depth, start := 0, 0
+	for i, t := range tokens {
+		if t.quoted {
+			continue
+		}
+		switch t.text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		case ",":
+			if depth == 0 {
+				elements = append(elements, tokens[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(elements, tokens[start:])
+}
+
+// createTable parses the rest of a CREATE TABLE statement.
+func (p *parser) createTable() (*domain.TableStructure, error) {
+	if p.accept("IF") {
+		p.accept("NOT")
+		p.accept("EXISTS")
+	}
+	table := &domain.TableStructure{Name: p.name()}
+
+	body, err := p.group()
+	if err != nil {
+		return nil, fmt.Errorf("table %s: %w", table.Name, err)
+	}
+	for _, element := range splitElements(body) {
+		if len(element) == 0 {
+			continue
+		}
+		elementParser := &parser{tokens: element}
+		if isTableElement(element) {
+			err = elementParser.tableElement(table)
+		} else {
+			err = elementParser.column(table)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("table %s: %w", table.Name, err)
+		}
+	}
+	if len(table.Columns) == 0 {
+		return nil, fmt.Errorf("table %s has no columns", table.Name)
+	}
+
+	return table, nil
+}
+
+// isTableElement reports whether the element of a CREATE TABLE is a constraint or index instead of a column.
+func isTableElement(element []token) bool {
+	return element[0].is("CONSTRAINT", "PRIMARY", "UNIQUE", "FOREIGN", "KEY", "INDEX", "FULLTEXT", "SPATIAL",
+		"CHECK", "EXCLUDE", "LIKE")
+}
+
+// tableElement parses a table constraint or index, of a CREATE TABLE or an ALTER TABLE ... ADD.
+//
+//nolint:cyclop // One branch per kind of element.
+func (p *parser) tableElement(table *domain.TableStructure) error {
+	name := ""
+	if p.accept("CONSTRAINT") {
+		if !p.peek().is("PRIMARY", "UNIQUE", "FOREIGN", "CHECK", "EXCLUDE") {
+			name = p.name()
+		}
+	}
+
+	switch {
+	case p.accept("PRIMARY"):
+		p.accept("KEY")
+		columns, prefixes, err := p.indexColumns()
+		if err != nil {
+			return err
+		}
+		table.Indexes = append(table.Indexes, domain.TableIndex{
+			Name: "PRIMARY", Columns: columns, IsUnique: true, IsPrimary: true, Prefixes: prefixes,
+		})
+	case p.accept("UNIQUE"):
+		if !p.accept("KEY") {
+			p.accept("INDEX")
+		}
+		if p.peek().text != "(" && !p.peek().is("USING") {
+			name = p.name()
+		}
+		columns, prefixes, err := p.indexColumns()
+		if err != nil {
+			return err
+		}
+		table.Indexes = append(table.Indexes, domain.TableIndex{
+			Name: indexName(name, table.Name, columns, "key"), Columns: columns, IsUnique: true, Prefixes: prefixes,
+		})
+	case p.accept("FOREIGN"):
+		p.accept("KEY")
+		if p.peek().text != "(" {
+			name = p.name()
+		}
+		columns, err := p.columnList()
+		if err != nil {
+			return err
+		}
+		if !p.accept("REFERENCES") {
+			return fmt.Errorf("foreign key on %s without REFERENCES", strings.Join(columns, ", "))
+		}
+		fk, err := p.references(columns)
+		if err != nil {
+			return err
+		}
+		fk.Name = indexName(name, table.Name, columns, "fkey")
+		table.ForeignKeys = append(table.ForeignKeys, fk)
+	case p.acceptSkipping("KEY", "FULLTEXT", "SPATIAL"), p.acceptSkipping("INDEX", "FULLTEXT", "SPATIAL"):
+		if p.peek().text != "(" && !p.peek().is("USING") {
+			name = p.name()
+		}
+		columns, prefixes, err := p.indexColumns()
+		if err != nil {
+			return err
+		}
+		table.Indexes = append(table.Indexes, domain.TableIndex{
+			Name: indexName(name, table.Name, columns, "idx"), Columns: columns, Prefixes: prefixes,
+		})
+	}
+
+	// CHECK, EXCLUDE and LIKE are not part of the table structure
+	return nil
+}
+
+// indexColumns reads the columns of an index, after an optional USING clause, and their MySQL key prefix
+// lengths, nil if no column has one.
+func (p *parser) indexColumns() ([]string, []int, error) {
+	if p.accept("USING") {
+		p.next()
+	}
+
+	tokens, err := p.group()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var columns []string
+	var prefixes []int
+	for _, element := range splitElements(tokens) {
+		if len(element) == 0 {
+			continue
+		}
+		columns = append(columns, element[0].text)
+		if len(element) > 2 && element[1].text == "(" {
+			if prefix, atoiErr := strconv.Atoi(element[2].text); atoiErr == nil {
+				if prefixes == nil {
+					prefixes = make([]int, len(columns)-1, len(columns))
+				}
+				prefixes = append(prefixes, prefix)
+				continue
+			}
+		}
+		if prefixes != nil {
+			prefixes = append(prefixes, 0)
+		}
+	}
+
+	return columns, prefixes, nil
+}
+
+// references parses the REFERENCES clause of a foreign key on the columns.
+func (p *parser) references(columns []string) (domain.ForeignKey, error) {
+	fk := domain.ForeignKey{Columns: columns, ReferencedTable: p.name()}
+	if p.peek().text == "(" {
+		referenced, err := p.columnList()
+		if err != nil {
+			return fk, err
+		}
+		fk.ReferencedColumns = referenced
+	}
+	if len(fk.ReferencedColumns) != len(fk.Columns) {
+		return fk, fmt.Errorf("foreign key on %s must list the referenced columns of %s",
+			strings.Join(columns, ", "), fk.ReferencedTable)
+	}
+
+	return fk, nil
+}
+
+// indexName returns the name of an index or constraint, or the one Postgres would generate if unnamed.
+func indexName(name, table string, columns []string, suffix string) string {
+	if name != "" {
+		return name
+	}
+
+	return table + "_" + strings.Join(columns, "_") + "_" + suffix
+}
+
+// column parses a column definition of a CREATE TABLE.
+//
+//nolint:cyclop // One branch per column attribute.
+func (p *parser) column(table *domain.TableStructure) error {
+	column := domain.TableColumn{Name: p.next().text, Nullable: true}
+
+	dataType, err := p.dataType()
+	if err != nil {
+		return fmt.Errorf("column %s: %w", column.Name, err)
+	}
+	column.DataType = dataType
+	if t := parseType(dataType); t.args != "" && (t.base == "decimal" || t.base == "numeric") {
+		_, _ = fmt.Sscanf(t.args, "%d,%d", &column.Precision, &column.Scale)
+	}
+
+	for !p.done() {
+		switch {
+		case p.accept("NOT"):
+			p.accept("NULL")
+			column.Nullable = false
+		case p.accept("NULL"):
+			column.Nullable = true
+		case p.accept("DEFAULT"):
+			column.Default = p.defaultValue()
+		case p.accept("PRIMARY"):
+			p.accept("KEY")
+			column.Nullable = false
+			table.Indexes = append(table.Indexes, domain.TableIndex{
+				Name: "PRIMARY", Columns: []string{column.Name}, IsUnique: true, IsPrimary: true,
+			})
+		case p.accept("UNIQUE"):
+			p.accept("KEY")
+			table.Indexes = append(table.Indexes, domain.TableIndex{
+				Name: indexName("", table.Name, []string{column.Name}, "key"), Columns: []string{column.Name}, IsUnique: true,
+			})
+		case p.accept("REFERENCES"):
+			fk, err := p.references([]string{column.Name})
+			if err != nil {
+				return err
+			}
+			fk.Name = indexName("", table.Name, []string{column.Name}, "fkey")
+			table.ForeignKeys = append(table.ForeignKeys, fk)
+		case p.peek().text == "(":
+			// Arguments of CHECK, GENERATED, ON UPDATE and such
+			if _, err = p.group(); err != nil {
+				return err
+			}
+		default:
+			p.next()
+		}
+	}
+
+	// Serial columns are integers with a sequence
+	switch strings.ToLower(column.DataType) {
+	case "serial", "serial4":
+		column.DataType, column.Nullable = "integer", false
+	case "bigserial", "serial8":
+		column.DataType, column.Nullable = "bigint", false
+	case "smallserial", "serial2":
+		column.DataType, column.Nullable = "smallint", false
+	}
+	table.Columns = append(table.Columns, column)
+
+	return nil
+}
+
+// columnAttributes are the keywords ending the data type of a column definition.
+var columnAttributes = []string{ //nolint:gochecknoglobals // Constant list.
+	"NOT", "NULL", "DEFAULT", "PRIMARY", "UNIQUE", "REFERENCES", "AUTO_INCREMENT", "COMMENT", "CHECK",
+	"CONSTRAINT", "COLLATE", "GENERATED", "ON", "AS", "KEY", "STORAGE", "COLUMN_FORMAT", "INVISIBLE", "VISIBLE",
+	"SRID",
+}
+
+// dataType reads the data type of a column: its words, with the lower case name, and the arguments.
+func (p *parser) dataType() (string, error) {
+	var words []string
+	for !p.done() && !p.peek().is(columnAttributes...) {
+		// CHARACTER SET ends the type, while CHARACTER VARYING is a type
+		if p.peek().is("CHARACTER", "CHARSET") && len(words) > 0 {
+			break
+		}
+		if p.peek().text == "(" {
+			args, err := p.group()
+			if err != nil {
+				return "", err
+			}
+			if len(words) == 0 {
+				return "", errors.New("missing data type")
+			}
+			words[len(words)-1] += "(" + joinTokens(args) + ")"
+			continue
+		}
+		if p.peek().text == "[" {
+			// Postgres arrays
+			p.next()
+			p.accept("]")
+			words = []string{"array"}
+			continue
+		}
+		words = append(words, strings.ToLower(p.next().text))
+	}
+	if len(words) == 0 {
+		return "", errors.New("missing data type")
+	}
+
+	return strings.Join(words, " "), nil
+}
+
+// defaultValue reads the default value of a column: a literal, possibly signed or cast, or an expression.
+func (p *parser) defaultValue() string {
+	start := p.pos
+	for !p.done() && (!p.peek().is(columnAttributes...) || p.pos == start && p.peek().is("NULL")) {
+		if p.peek().text == "(" {
+			if _, err := p.group(); err != nil {
+				break
+			}
+			continue
+		}
+		p.next()
+	}
+
+	value := p.tokens[start:p.pos]
+	if len(value) == 1 && value[0].quoted {
+		// MySQL accepts double quoted strings
+		return "'" + strings.ReplaceAll(value[0].text, "'", "''") + "'"
+	}
+
+	return joinTokens(value)
+}
+
+// joinTokens returns the text of the tokens, separated by spaces except around punctuation.
+func joinTokens(tokens []token) string {
+	var text strings.Builder
+	for i, t := range tokens {
+		if i > 0 && isWordToken(t) && isWordToken(tokens[i-1]) {
+			text.WriteByte(' ')
+		}
+		if t.quoted {
+			text.WriteString(`"` + t.text + `"`)
+		} else {
+			text.WriteString(t.text)
+		}
+	}
+
+	return text.String()
+}
+
+func isWordToken(t token) bool {
+	return t.quoted || t.text != "" && (isWordByte(t.text[0]) || t.text[0] == '\'')
+}
+
+// createIndex parses the rest of a CREATE [UNIQUE] INDEX statement, returning the index and its table.
+func (p *parser) createIndex() (domain.TableIndex, string, error) {
+	index := domain.TableIndex{IsUnique: p.tokens[1].is("UNIQUE")}
+
+	p.accept("CONCURRENTLY")
+	if p.accept("IF") {
+		p.accept("NOT")
+		p.accept("EXISTS")
+	}
+	if !p.peek().is("ON") {
+		index.Name = p.name()
+	}
+	if !p.accept("ON") {
+		return index, "", errors.New("CREATE INDEX without ON")
+	}
+	p.accept("ONLY")
+	table := p.name()
+
+	columns, prefixes, err := p.indexColumns()
+	if err != nil {
+		return index, "", fmt.Errorf("index on %s: %w", table, err)
+	}
+	index.Columns, index.Prefixes = columns, prefixes
+	index.Name = indexName(index.Name, table, columns, "idx")
+
+	return index, table, nil
+}
@@ -0,0 +1,281 @@
+// Package ddl renders the CREATE TABLE, CREATE INDEX and foreign key statements of a table structure for
+// MySQL or Postgres, translating the data types of the other dialect, and parses them back from DDL files.
+package ddl
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+)
+
+// defaultKeyPrefix is the key prefix length of the MySQL text and blob columns without one, which cannot be
+// indexed whole.
+const defaultKeyPrefix = 255
+
+// Statements are the statements creating a table: the table with its columns and primary key, its secondary
+// indexes and its foreign keys, in the order of the table structure.
+type Statements struct {
+	CreateTable string
+	Indexes     []string
+	ForeignKeys []string
+}
+
+// All returns all the statements, in execution order.
+func (s *Statements) All() []string {
+	return slices.Concat([]string{s.CreateTable}, s.Indexes, s.ForeignKeys)
+}
+
+// Render returns the statements creating the table in the database type. Integer single column primary keys
+// are created as auto-increment or identity columns, as the loader leaves them to the database.
+func Render(table *domain.TableStructure, dbType string) (*Statements, error) {
+	if dbType != "mysql" && dbType != "postgres" {
+		return nil, fmt.Errorf("unsupported database type: %s", dbType)
+	}
+	if len(table.Columns) == 0 {
+		return nil, fmt.Errorf("table %s has no columns", table.Name)
+	}
+
+	q := quoter(dbType)
+	var primaryKey []string
+	primaryIndex := -1
+	for i, index := range table.Indexes {
+		if index.IsPrimary {
+			primaryKey, primaryIndex = index.Columns, i
+		}
+	}
+
+	definitions := make([]string, 0, len(table.Columns)+1)
+	for _, column := range table.Columns {
+		definitions = append(definitions, renderColumn(column, dbType, primaryKey))
+	}
+	if len(primaryKey) > 0 {
+		definitions = append(definitions, fmt.Sprintf("PRIMARY KEY (%s)",
+			keyList(table, table.Indexes[primaryIndex], dbType)))
+	}
+
+	statements := &Statements{
+		CreateTable: fmt.Sprintf("CREATE TABLE %s (\n  %s\n)", q.table(table.Name), strings.Join(definitions, ",\n  ")),
+	}
+
+	for _, index := range table.Indexes {
+		if index.IsPrimary {
+			continue
+		}
+		unique := ""
+		if index.IsUnique {
+			unique = "UNIQUE "
+		}
+		statements.Indexes = append(statements.Indexes, fmt.Sprintf("CREATE %sINDEX %s ON %s (%s)",
+			unique, q.identifier(index.Name), q.table(table.Name), keyList(table, index, dbType)))
+	}
+
+	for _, fk := range table.ForeignKeys {
+		constraint := ""
+		if fk.Name != "" {
+			constraint = "CONSTRAINT " + q.identifier(fk.Name) + " "
+		}
+		statements.ForeignKeys = append(statements.ForeignKeys, fmt.Sprintf(
+			"ALTER TABLE %s ADD %sFOREIGN KEY (%s) REFERENCES %s (%s)", q.table(table.Name), constraint,
+			q.list(fk.Columns), q.table(fk.ReferencedTable), q.list(fk.ReferencedColumns)))
+	}
+
+	return statements, nil
+}
+
+// keyList returns the quoted columns of the index. On MySQL the columns keep their key prefix lengths, and
+// text and blob columns without one get the default prefix length.
+func keyList(table *domain.TableStructure, index domain.TableIndex, dbType string) string {
+	q := quoter(dbType)
+	if dbType != "mysql" {
+		return q.list(index.Columns)
+	}
+
+	keys := make([]string, len(index.Columns))
+	for i, name := range index.Columns {
+		keys[i] = q.identifier(name)
+
+		prefix := 0
+		if i < len(index.Prefixes) {
+			prefix = index.Prefixes[i]
+		}
+		if prefix == 0 {
+			for _, column := range table.Columns {
+				base := parseType(mysqlType(column)).base
+				if column.Name == name && (strings.HasSuffix(base, "text") || strings.HasSuffix(base, "blob")) {
+					prefix = defaultKeyPrefix
+				}
+			}
+		}
+		if prefix > 0 {
+			keys[i] += "(" + strconv.Itoa(prefix) + ")"
+		}
+	}
+
+	return strings.Join(keys, ", ")
+}
+
+// renderColumn returns the definition of the column in the CREATE TABLE statement.
+func renderColumn(column domain.TableColumn, dbType string, primaryKey []string) string {
+	dataType := mysqlType(column)
+	if dbType == "postgres" {
+		dataType = postgresType(column)
+	}
+
+	definition := quoter(dbType).identifier(column.Name) + " " + dataType
+	if !column.Nullable {
+		definition += " NOT NULL"
+	}
+
+	if isAutoIncrement(column, primaryKey) {
+		if dbType == "postgres" {
+			if parseType(dataType).base == "numeric" {
+				// Identity columns are integers, numeric(20,0) only holds the unsigned MySQL bigints
+				definition = quoter(dbType).identifier(column.Name) + " bigint NOT NULL"
+			}
+			return definition + " GENERATED BY DEFAULT AS IDENTITY"
+		}
+		return definition + " AUTO_INCREMENT"
+	}
+
+	if value, ok := renderDefault(column.Default, dataType, dbType); ok {
+		definition += " DEFAULT " + value
+	}
+
+	return definition
+}
+
+// isAutoIncrement reports whether the column is an integer single column primary key or a Postgres serial.
+func isAutoIncrement(column domain.TableColumn, primaryKey []string) bool {
+	if strings.HasPrefix(strings.ToLower(column.Default), "nextval(") ||
+		strings.Contains(strings.ToLower(column.DataType), "serial") {
+		return true
+	}
+
+	if len(primaryKey) != 1 || primaryKey[0] != column.Name {
+		return false
+	}
+	switch parseType(column.DataType).base {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint", "int1", "int2", "int4", "int8":
+		return true
+	default:
+		return false
+	}
+}
+
+// renderDefault returns the default value of a column in the dialect. The parsers return the defaults as the
+// databases store them: MySQL without quotes and Postgres with casts, like 'new'::character varying.
+// Expressions other than the current date and time are not translated and left out, as well as the defaults
+// of the MySQL types not accepting literal defaults.
+func renderDefault(value, dataType, dbType string) (string, bool) {
+	value = stripCast(strings.TrimSpace(value))
+	lower := strings.ToLower(value)
+	base := parseType(dataType).base
+
+	switch {
+	case value == "" || lower == "null" || strings.HasPrefix(lower, "nextval("):
+		return "", false
+	case dbType == "mysql" && (strings.Contains(base, "text") || strings.Contains(base, "blob") || base == "json"):
+		return "", false
+	case isCurrentTime(lower):
+		return renderCurrentTime(lower, dataType, dbType), true
+	case isNumber(value), lower == "true", lower == "false":
+		return value, true
+	case strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") && len(value) >= 2:
+		return value, true
+	case strings.ContainsAny(value, "()"):
+		return "", false
+	default:
+		return "'" + strings.ReplaceAll(value, "'", "''") + "'", true
+	}
+}
+
+// stripCast removes the Postgres casts of a default value.
+func stripCast(value string) string {
+	if strings.HasPrefix(value, "'") {
+		if end := strings.LastIndex(value, "'"); end > 0 && strings.HasPrefix(value[end+1:], "::") {
+			return value[:end+1]
+		}
+		return value
+	}
+	if start := strings.Index(value, "::"); start != -1 {
+		value = value[:start]
+	}
+
+	if strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		return value[1 : len(value)-1]
+	}
+
+	return value
+}
+
+func isNumber(value string) bool {
+	_, err := strconv.ParseFloat(value, 64)
+	return err == nil
+}
+
+func isCurrentTime(lower string) bool {
+	name, _, _ := strings.Cut(lower, "(")
+	switch strings.TrimSpace(name) {
+	case "current_timestamp", "now", "localtimestamp", "current_date", "curdate", "current_time", "curtime":
+		return true
+	default:
+		return false
+	}
+}
+
+// renderCurrentTime returns the current date or time function of the dialect, with the fractional seconds of
+// the type, which MySQL requires to match.
+func renderCurrentTime(lower, dataType, dbType string) string {
+	name, _, _ := strings.Cut(lower, "(")
+	switch strings.TrimSpace(name) {
+	case "current_date", "curdate":
+		if dbType == "mysql" {
+			return "(CURRENT_DATE)"
+		}
+		return "CURRENT_DATE"
+	case "current_time", "curtime":
+		if dbType == "mysql" {
+			return "(CURRENT_TIME)"
+		}
+		return "CURRENT_TIME"
+	}
+
+	if t := parseType(dataType); dbType == "mysql" && t.args != "" {
+		return "CURRENT_TIMESTAMP(" + t.args + ")"
+	}
+
+	return "CURRENT_TIMESTAMP"
+}
+
+// quoter quotes the identifiers of a dialect.
+type quoter string
+
+func (q quoter) identifier(name string) string {
+	if q == "mysql" {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// table quotes a table name, which may be qualified by its schema.
+func (q quoter) table(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = q.identifier(part)
+	}
+
+	return strings.Join(parts, ".")
+}
+
+func (q quoter) list(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = q.identifier(name)
+	}
+
+	return strings.Join(quoted, ", ")
+}
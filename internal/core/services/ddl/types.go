@@ -0,0 +1,219 @@
+package ddl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+)
+
+// defaultLength is the length of the character columns whose length is unknown, as the parsers do not
+// return it.
+const defaultLength = 255
+
+// columnType is a data type split in its parts: "int(11) unsigned" is the base int with the arguments 11
+// and the unsigned flag.
+type columnType struct {
+	base     string // Lower case words of the name, like "character varying" or "timestamp"
+	args     string // Arguments between the parentheses, if any
+	unsigned bool
+	withTZ   bool
+}
+
+// parseType splits a data type of any of the dialects.
+func parseType(dataType string) columnType {
+	var t columnType
+
+	rest := dataType
+	if start := strings.Index(dataType, "("); start != -1 {
+		if end := strings.LastIndex(dataType, ")"); end > start {
+			t.args = strings.TrimSpace(dataType[start+1 : end])
+			rest = dataType[:start] + " " + dataType[end+1:]
+		}
+	}
+
+	lower := strings.ToLower(rest)
+	t.withTZ = strings.Contains(lower, "with time zone")
+	lower = strings.NewReplacer("without time zone", "", "with time zone", "").Replace(lower)
+
+	var words []string
+	for _, word := range strings.Fields(lower) {
+		switch word {
+		case "unsigned":
+			t.unsigned = true
+		case "zerofill":
+		default:
+			words = append(words, word)
+		}
+	}
+	t.base = strings.Join(words, " ")
+
+	return t
+}
+
+// withArgs returns the name with the arguments of the type, or the default arguments if it has none.
+func (t columnType) withArgs(name, defaultArgs string) string {
+	args := t.args
+	if args == "" {
+		args = defaultArgs
+	}
+	if args == "" {
+		return name
+	}
+
+	return name + "(" + args + ")"
+}
+
+// numericArgs returns the precision and scale of a decimal column, from the type or the column fields.
+func numericArgs(t columnType, column domain.TableColumn, defaultArgs string) string {
+	if t.args != "" {
+		return t.args
+	}
+	if column.Precision > 0 {
+		return fmt.Sprintf("%d,%d", column.Precision, column.Scale)
+	}
+
+	return defaultArgs
+}
+
+// mysqlType returns the MySQL type of the column. Unknown types are kept as they are.
+//
+//nolint:cyclop // A flat list of type mappings.
+func mysqlType(column domain.TableColumn) string {
+	t := parseType(column.DataType)
+	unsigned := ""
+	if t.unsigned {
+		unsigned = " unsigned"
+	}
+
+	switch t.base {
+	case "tinyint", "int1":
+		if t.args == "1" {
+			// The MySQL booleans
+			return "tinyint(1)" + unsigned
+		}
+		return "tinyint" + unsigned
+	case "smallint", "int2", "smallserial", "serial2":
+		return "smallint" + unsigned
+	case "mediumint":
+		return "mediumint" + unsigned
+	case "int", "integer", "int4", "serial", "serial4":
+		return "int" + unsigned
+	case "bigint", "int8", "bigserial", "serial8":
+		return "bigint" + unsigned
+	case "float", "real", "float4":
+		return "float"
+	case "double", "double precision", "float8":
+		return "double"
+	case "decimal", "numeric", "dec", "fixed":
+		return "decimal(" + numericArgs(t, column, "38,10") + ")"
+	case "money":
+		return "decimal(19,2)"
+	case "bool", "boolean":
+		return "tinyint(1)"
+	case "char", "character", "bpchar", "nchar":
+		return t.withArgs("char", "255")
+	case "varchar", "character varying", "nvarchar":
+		return t.withArgs("varchar", fmt.Sprint(defaultLength))
+	case "text", "citext", "name":
+		return "text"
+	case "bytea":
+		return "longblob"
+	case "timestamp", "timestamptz", "datetime":
+		return t.withArgs("datetime", "6")
+	case "time", "timetz":
+		return t.withArgs("time", "6")
+	case "json", "jsonb", "array":
+		return "json"
+	case "uuid":
+		return "char(36)"
+	case "inet", "cidr":
+		return "varchar(43)"
+	case "macaddr", "macaddr8":
+		return "varchar(23)"
+	case "interval", "user-defined":
+		return "varchar(255)"
+	case "bit varying", "varbit":
+		return t.withArgs("bit", "64")
+	case "binary", "varbinary":
+		return t.withArgs(t.base, fmt.Sprint(defaultLength))
+	case "enum", "set":
+		if t.args == "" {
+			// The values are unknown when the structure comes from the parsers
+			return "varchar(255)"
+		}
+		return t.withArgs(t.base, "")
+	default:
+		return t.withArgs(t.base, "")
+	}
+}
+
+// postgresType returns the Postgres type of the column. Unknown types are kept as they are.
+//
+//nolint:cyclop // A flat list of type mappings.
+func postgresType(column domain.TableColumn) string {
+	t := parseType(column.DataType)
+
+	switch t.base {
+	case "tinyint", "int1":
+		if t.args == "1" {
+			return "boolean"
+		}
+		return "smallint"
+	case "smallint", "int2", "smallserial", "serial2":
+		if t.unsigned {
+			return "integer"
+		}
+		return "smallint"
+	case "mediumint", "int", "integer", "int4", "serial", "serial4":
+		if t.unsigned {
+			return "bigint"
+		}
+		return "integer"
+	case "bigint", "int8", "bigserial", "serial8":
+		if t.unsigned {
+			return "numeric(20,0)"
+		}
+		return "bigint"
+	case "float", "real", "float4":
+		return "real"
+	case "double", "double precision", "float8":
+		return "double precision"
+	case "decimal", "numeric", "dec", "fixed":
+		return "numeric(" + numericArgs(t, column, "38,10") + ")"
+	case "bool", "boolean":
+		return "boolean"
+	case "char", "character", "bpchar", "nchar":
+		return t.withArgs("char", "255")
+	case "varchar", "character varying", "nvarchar":
+		return t.withArgs("varchar", fmt.Sprint(defaultLength))
+	case "tinytext", "text", "mediumtext", "longtext", "enum", "set":
+		return "text"
+	case "tinyblob", "blob", "mediumblob", "longblob", "binary", "varbinary":
+		return "bytea"
+	case "datetime", "timestamp", "timestamptz":
+		name := "timestamp"
+		if t.withTZ || t.base == "timestamptz" {
+			name = "timestamptz"
+		}
+		return t.withArgs(name, "")
+	case "time", "timetz":
+		name := "time"
+		if t.withTZ || t.base == "timetz" {
+			name = "timetz"
+		}
+		return t.withArgs(name, "")
+	case "user-defined":
+		return "text"
+	case "array":
+		return "text[]"
+	case "year":
+		return "smallint"
+	case "json":
+		return "json"
+	case "geometry":
+		return "point"
+	default:
+		return t.withArgs(t.base, "")
+	}
+}
@@ -0,0 +1,175 @@
+// Package dsn builds the connection strings of the database drivers from separate connection settings.
+package dsn
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQL defaults, used when neither the settings nor the option file set them.
+const (
+	defaultMySQLHost = "localhost"
+	defaultMySQLPort = 3306
+	defaultMySQLUser = "root"
+)
+
+// Params are the settings a DSN is built from. Empty settings take the driver defaults.
+type Params struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	DBName   string
+}
+
+// IsZero reports whether no setting is set.
+func (p Params) IsZero() bool {
+	return p == Params{}
+}
+
+// WithDefaults returns the params with the empty settings taken from defaults.
+func (p Params) WithDefaults(defaults Params) Params {
+	if p.Host == "" {
+		p.Host = defaults.Host
+	}
+	if p.Port == 0 {
+		p.Port = defaults.Port
+	}
+	if p.User == "" {
+		p.User = defaults.User
+	}
+	if p.Password == "" {
+		p.Password = defaults.Password
+	}
+	if p.DBName == "" {
+		p.DBName = defaults.DBName
+	}
+
+	return p
+}
+
+// Build returns the DSN of the database type. Postgres settings left empty are resolved by the driver
+// from the PG* environment variables, and the password from PGPASSFILE or ~/.pgpass.
+func Build(dbType string, p Params) (string, error) {
+	switch dbType {
+	case "mysql":
+		return buildMySQL(p), nil
+	case "postgres":
+		return buildPostgres(p), nil
+	default:
+		return "", fmt.Errorf("unsupported database type: %s", dbType)
+	}
+}
+
+func buildMySQL(p Params) string {
+	p = p.WithDefaults(Params{Host: defaultMySQLHost, Port: defaultMySQLPort, User: defaultMySQLUser})
+
+	cfg := mysql.NewConfig()
+	cfg.User = p.User
+	cfg.Passwd = p.Password
+	cfg.DBName = p.DBName
+	if strings.HasPrefix(p.Host, "/") {
+		cfg.Net, cfg.Addr = "unix", p.Host
+	} else {
+		cfg.Net, cfg.Addr = "tcp", net.JoinHostPort(p.Host, strconv.Itoa(p.Port))
+	}
+
+	return cfg.FormatDSN()
+}
+
+// buildPostgres returns a key/value connection string with the settings that are set.
+func buildPostgres(p Params) string {
+	var settings []string
+	add := func(key, value string) {
+		if value != "" {
+			settings = append(settings, key+"="+QuotePostgres(value))
+		}
+	}
+
+	add("host", p.Host)
+	if p.Port != 0 {
+		add("port", strconv.Itoa(p.Port))
+	}
+	add("user", p.User)
+	add("password", p.Password)
+	add("dbname", p.DBName)
+
+	return strings.Join(settings, " ")
+}
+
+// QuotePostgres quotes a Postgres connection string value, escaping backslashes and quotes.
+func QuotePostgres(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// ReadMyCnf reads the user, password, host, port and database of the [client] and [mysql] groups of a
+// MySQL option file like ~/.my.cnf. A missing file yields empty params.
+func ReadMyCnf(path string) (Params, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Params{}, nil
+	}
+	if err != nil {
+		return Params{}, fmt.Errorf("cannot read MySQL option file: %w", err)
+	}
+	defer f.Close()
+
+	var p Params
+	group := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || line[0] == '#' || line[0] == ';':
+			continue
+		case line[0] == '[':
+			group = strings.ToLower(strings.Trim(line, "[]"))
+			continue
+		case group != "client" && group != "mysql":
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, "=")
+		key = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(key)), "_", "-")
+		value = unquoteMyCnf(strings.TrimSpace(value))
+		switch key {
+		case "user":
+			p.User = value
+		case "password":
+			p.Password = value
+		case "host":
+			p.Host = value
+		case "socket":
+			if p.Host == "" {
+				p.Host = value
+			}
+		case "port":
+			if p.Port, err = strconv.Atoi(value); err != nil {
+				return Params{}, fmt.Errorf("invalid port %q in %s", value, path)
+			}
+		case "database":
+			p.DBName = value
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return Params{}, fmt.Errorf("cannot read MySQL option file: %w", err)
+	}
+
+	return p, nil
+}
+
+func unquoteMyCnf(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+
+	return value
+}
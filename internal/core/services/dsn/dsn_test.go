@@ -0,0 +1,76 @@
+package dsn_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/services/dsn"
+)
+
+func TestBuildMySQL(t *testing.T) {
+	built, err := dsn.Build("mysql", dsn.Params{DBName: "shop"})
+	require.NoError(t, err)
+	assert.Equal(t, "root@tcp(localhost:3306)/shop", built)
+
+	built, err = dsn.Build("mysql", dsn.Params{Host: "db", Port: 3307, User: "app", Password: "p@ss:w0rd", DBName: "shop"})
+	require.NoError(t, err)
+	cfg, err := mysql.ParseDSN(built)
+	require.NoError(t, err)
+	assert.Equal(t, "db:3307", cfg.Addr)
+	assert.Equal(t, "app", cfg.User)
+	assert.Equal(t, "p@ss:w0rd", cfg.Passwd)
+	assert.Equal(t, "shop", cfg.DBName)
+
+	built, err = dsn.Build("mysql", dsn.Params{Host: "/var/run/mysqld/mysqld.sock"})
+	require.NoError(t, err)
+	assert.Equal(t, "root@unix(/var/run/mysqld/mysqld.sock)/", built)
+
+	_, err = dsn.Build("oracle", dsn.Params{})
+	require.Error(t, err)
+}
+
+func TestBuildPostgres(t *testing.T) {
+	built, err := dsn.Build("postgres", dsn.Params{})
+	require.NoError(t, err)
+	assert.Empty(t, built, "the driver defaults apply")
+
+	built, err = dsn.Build("postgres", dsn.Params{Host: "db", Port: 5433, User: "app", Password: `it's\secret`, DBName: "shop"})
+	require.NoError(t, err)
+	assert.Equal(t, `host='db' port='5433' user='app' password='it\'s\\secret' dbname='shop'`, built)
+}
+
+func TestReadMyCnf(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".my.cnf")
+	require.NoError(t, os.WriteFile(path, []byte(`
+# Credentials
+[mysqld]
+port = 3300
+
+[client]
+user = app
+password = "secret # not a comment"
+host=db.local
+port=3307
+
+[mysqldump]
+user = backup
+`), 0o600))
+
+	p, err := dsn.ReadMyCnf(path)
+	require.NoError(t, err)
+	assert.Equal(t, dsn.Params{Host: "db.local", Port: 3307, User: "app", Password: "secret # not a comment"}, p)
+
+	p, err = dsn.ReadMyCnf(filepath.Join(t.TempDir(), "missing"))
+	require.NoError(t, err)
+	assert.True(t, p.IsZero())
+}
+
+func TestWithDefaults(t *testing.T) {
+	p := dsn.Params{User: "app"}.WithDefaults(dsn.Params{User: "root", Password: "secret", Port: 3306})
+	assert.Equal(t, dsn.Params{User: "app", Password: "secret", Port: 3306}, p)
+}
@@ -0,0 +1,65 @@
+package filesink
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+)
+
+// CSVSink writes rows as RFC 4180 comma separated values with a header line.
+// NULL values are written as the NULL marker, an empty field by default.
+type CSVSink struct {
+	out     io.WriteCloser
+	writer  *csv.Writer
+	null    string
+	columns []domain.TableColumn
+}
+
+// NewCSVSink creates a new CSV sink writing to out, with null written for NULL values, e.g. \N.
+func NewCSVSink(out io.WriteCloser, null string) *CSVSink {
+	return &CSVSink{
+		out:    out,
+		writer: csv.NewWriter(out),
+		null:   null,
+	}
+}
+
+// Begin writes the header line.
+func (s *CSVSink) Begin(_ *domain.TableStructure, columns []domain.TableColumn) error {
+	s.columns = columns
+
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.Name
+	}
+
+	return s.writer.Write(header)
+}
+
+// WriteBatch writes one record per row.
+func (s *CSVSink) WriteBatch(rows [][]any) error {
+	record := make([]string, len(s.columns))
+	for _, row := range rows {
+		for i, value := range row {
+			text, null := textValue(value, s.columns[i].DataType)
+			if null {
+				text = s.null
+			}
+			record[i] = text
+		}
+		if err := s.writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close flushes the pending records and closes the output.
+func (s *CSVSink) Close() error {
+	s.writer.Flush()
+
+	return errors.Join(s.writer.Error(), s.out.Close())
+}
@@ -0,0 +1,136 @@
+// Package filesink implements dataloader sinks that write generated rows to files
+package filesink
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/dataloader"
+)
+
+// Supported output formats.
+const (
+	FormatCSV   = "csv"
+	FormatTSV   = "tsv"
+	FormatJSONL = "jsonl"
+)
+
+// Supported compression algorithms.
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+// New creates the file at path and returns a sink writing rows in the given format and compression.
+// CSV files write null for NULL values.
+func New(path, format, compression, null string) (dataloader.Sink, error) {
+	switch format {
+	case FormatCSV, FormatTSV, FormatJSONL:
+	default:
+		return nil, fmt.Errorf("unsupported output format: %s", format)
+	}
+
+	out, err := Create(path, compression)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatTSV:
+		return NewTSVSink(out), nil
+	case FormatJSONL:
+		return NewJSONLSink(out), nil
+	default:
+		return NewCSVSink(out, null), nil
+	}
+}
+
+// Create creates the file at path and wraps it with a buffered, optionally compressed, writer.
+// Closing the returned writer flushes the compressor and closes the file.
+func Create(path, compression string) (io.WriteCloser, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &fileWriter{file: file}
+	var dst io.Writer = file
+
+	switch compression {
+	case "", CompressionNone:
+	case CompressionGzip:
+		gz := gzip.NewWriter(file)
+		dst, out.compressor = gz, gz
+	case CompressionZstd:
+		zw, zstdErr := zstd.NewWriter(file)
+		if zstdErr != nil {
+			_ = file.Close()
+			return nil, fmt.Errorf("cannot create zstd writer: %w", zstdErr)
+		}
+		dst, out.compressor = zw, zw
+	default:
+		_ = file.Close()
+		return nil, fmt.Errorf("unsupported compression: %s", compression)
+	}
+
+	out.buf = bufio.NewWriter(dst)
+
+	return out, nil
+}
+
+// fileWriter flushes the buffer and the compressor before closing the underlying file.
+type fileWriter struct {
+	buf        *bufio.Writer
+	compressor io.Closer
+	file       *os.File
+}
+
+func (w *fileWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *fileWriter) Close() error {
+	err := w.buf.Flush()
+	if w.compressor != nil {
+		err = errors.Join(err, w.compressor.Close())
+	}
+
+	return errors.Join(err, w.file.Close())
+}
+
+// textValue renders a generated value as plain text. The boolean result is true for NULL values.
+func textValue(value any, dataType string) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", true
+	case string:
+		return v, false
+	case []byte:
+		// Same representation Postgres uses for bytea in text mode
+		return `\x` + hex.EncodeToString(v), false
+	case time.Time:
+		if strings.EqualFold(dataType, "date") {
+			return v.Format(time.DateOnly), false
+		}
+		return v.Format(time.RFC3339Nano), false
+	case bool:
+		return strconv.FormatBool(v), false
+	case int64:
+		return strconv.FormatInt(v, 10), false
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), false
+	default:
+		return fmt.Sprint(v), false
+	}
+}
@@ -0,0 +1,121 @@
+package filesink_test
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+	"github.com/cfsalguero/random_data_loader/internal/core/services/filesink"
+)
+
+var testColumns = []domain.TableColumn{ //nolint:gochecknoglobals // Test fixture.
+	{Name: "id", DataType: "int"},
+	{Name: "name", DataType: "varchar"},
+	{Name: "created", DataType: "date"},
+	{Name: "doc", DataType: "json"},
+	{Name: "data", DataType: "bytea"},
+}
+
+func testRows() [][]any {
+	created := time.Date(2020, 2, 3, 4, 5, 6, 0, time.UTC)
+	return [][]any{
+		{int64(1), `say "hi", bye`, created, `{"a": 1}`, []byte{0xde, 0xad}},
+		{int64(2), "tab\there\nnewline", created, nil, nil},
+	}
+}
+
+func writeFile(t *testing.T, format, compression string) string {
+	t.Helper()
+
+	return writeFileWithNull(t, format, compression, "")
+}
+
+func writeFileWithNull(t *testing.T, format, compression, null string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "out."+format)
+	sink, err := filesink.New(path, format, compression, null)
+	require.NoError(t, err)
+
+	require.NoError(t, sink.Begin(&domain.TableStructure{Name: "t", Columns: testColumns}, testColumns))
+	require.NoError(t, sink.WriteBatch(testRows()))
+	require.NoError(t, sink.Close())
+
+	return path
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	return string(data)
+}
+
+func TestCSVSink(t *testing.T) {
+	got := readFile(t, writeFile(t, filesink.FormatCSV, filesink.CompressionNone))
+
+	want := "id,name,created,doc,data\n" +
+		`1,"say ""hi"", bye",2020-02-03,"{""a"": 1}",\xdead` + "\n" +
+		"2,\"tab\there\nnewline\",2020-02-03,,\n"
+	assert.Equal(t, want, got)
+
+	got = readFile(t, writeFileWithNull(t, filesink.FormatCSV, filesink.CompressionNone, `\N`))
+	assert.Contains(t, got, "\n2,\"tab\there\nnewline\",2020-02-03,\\N,\\N\n")
+}
+
+func TestTSVSink(t *testing.T) {
+	got := readFile(t, writeFile(t, filesink.FormatTSV, filesink.CompressionNone))
+
+	want := "id\tname\tcreated\tdoc\tdata\n" +
+		"1\tsay \"hi\", bye\t2020-02-03\t{\"a\": 1}\t\\\\xdead\n" +
+		"2\ttab\\there\\nnewline\t2020-02-03\t\\N\t\\N\n"
+	assert.Equal(t, want, got)
+}
+
+func TestJSONLSink(t *testing.T) {
+	got := readFile(t, writeFile(t, filesink.FormatJSONL, filesink.CompressionNone))
+
+	want := `{"id":1,"name":"say \"hi\", bye","created":"2020-02-03","doc":{"a": 1},"data":"3q0="}` + "\n" +
+		`{"id":2,"name":"tab\there\nnewline","created":"2020-02-03","doc":null,"data":null}` + "\n"
+	assert.Equal(t, want, got)
+}
+
+func TestCompression(t *testing.T) {
+	plain := readFile(t, writeFile(t, filesink.FormatCSV, filesink.CompressionNone))
+
+	gzFile, err := os.Open(writeFile(t, filesink.FormatCSV, filesink.CompressionGzip))
+	require.NoError(t, err)
+	defer gzFile.Close()
+
+	gz, err := gzip.NewReader(gzFile)
+	require.NoError(t, err)
+	got, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	assert.Equal(t, plain, string(got))
+
+	zstFile, err := os.Open(writeFile(t, filesink.FormatCSV, filesink.CompressionZstd))
+	require.NoError(t, err)
+	defer zstFile.Close()
+
+	zr, err := zstd.NewReader(zstFile)
+	require.NoError(t, err)
+	defer zr.Close()
+	got, err = io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, plain, string(got))
+}
+
+func TestUnsupportedFormat(t *testing.T) {
+	_, err := filesink.New(filepath.Join(t.TempDir(), "out"), "xml", filesink.CompressionNone, "")
+	require.Error(t, err)
+}
@@ -0,0 +1,90 @@
+package filesink
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+)
+
+// JSONLSink writes rows as JSON Lines, one object per row with the keys in column order.
+// Values of json/jsonb columns are embedded as JSON instead of strings.
+type JSONLSink struct {
+	out     io.WriteCloser
+	columns []domain.TableColumn
+	keys    [][]byte
+	line    bytes.Buffer
+}
+
+// NewJSONLSink creates a new JSON Lines sink writing to out.
+func NewJSONLSink(out io.WriteCloser) *JSONLSink {
+	return &JSONLSink{out: out}
+}
+
+// Begin encodes the object keys once for all the rows.
+func (s *JSONLSink) Begin(_ *domain.TableStructure, columns []domain.TableColumn) error {
+	s.columns = columns
+	s.keys = make([][]byte, len(columns))
+
+	for i, column := range columns {
+		key, err := json.Marshal(column.Name)
+		if err != nil {
+			return err
+		}
+		s.keys[i] = key
+	}
+
+	return nil
+}
+
+// WriteBatch writes one JSON object per row.
+func (s *JSONLSink) WriteBatch(rows [][]any) error {
+	for _, row := range rows {
+		s.line.Reset()
+		s.line.WriteByte('{')
+
+		for i, value := range row {
+			if i > 0 {
+				s.line.WriteByte(',')
+			}
+			s.line.Write(s.keys[i])
+			s.line.WriteByte(':')
+
+			encoded, err := s.encodeValue(value, s.columns[i].DataType)
+			if err != nil {
+				return err
+			}
+			s.line.Write(encoded)
+		}
+
+		s.line.WriteString("}\n")
+		if _, err := s.out.Write(s.line.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close closes the output.
+func (s *JSONLSink) Close() error {
+	return s.out.Close()
+}
+
+func (s *JSONLSink) encodeValue(value any, dataType string) ([]byte, error) {
+	switch v := value.(type) {
+	case string:
+		if strings.HasPrefix(strings.ToLower(dataType), "json") && json.Valid([]byte(v)) {
+			return []byte(v), nil
+		}
+	case time.Time:
+		// Dates as in the CSV and TSV files
+		text, _ := textValue(v, dataType)
+		return json.Marshal(text)
+	}
+
+	return json.Marshal(value)
+}
@@ -0,0 +1,71 @@
+package filesink
+
+import (
+	"io"
+	"strings"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+)
+
+// tsvEscaper escapes values the same way as the Postgres COPY text format.
+var tsvEscaper = strings.NewReplacer( //nolint:gochecknoglobals // Immutable replacer.
+	`\`, `\\`,
+	"\t", `\t`,
+	"\n", `\n`,
+	"\r", `\r`,
+)
+
+// TSVSink writes rows as tab separated values with a header line.
+// NULL values are written as \N, so the output can be loaded with COPY or LOAD DATA.
+type TSVSink struct {
+	out     io.WriteCloser
+	columns []domain.TableColumn
+}
+
+// NewTSVSink creates a new TSV sink writing to out.
+func NewTSVSink(out io.WriteCloser) *TSVSink {
+	return &TSVSink{out: out}
+}
+
+// Begin writes the header line.
+func (s *TSVSink) Begin(_ *domain.TableStructure, columns []domain.TableColumn) error {
+	s.columns = columns
+
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = tsvEscaper.Replace(column.Name)
+	}
+
+	return s.writeLine(names)
+}
+
+// WriteBatch writes one line per row.
+func (s *TSVSink) WriteBatch(rows [][]any) error {
+	fields := make([]string, len(s.columns))
+	for _, row := range rows {
+		for i, value := range row {
+			text, isNull := textValue(value, s.columns[i].DataType)
+			if isNull {
+				fields[i] = `\N`
+				continue
+			}
+			fields[i] = tsvEscaper.Replace(text)
+		}
+		if err := s.writeLine(fields); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close closes the output.
+func (s *TSVSink) Close() error {
+	return s.out.Close()
+}
+
+func (s *TSVSink) writeLine(fields []string) error {
+	_, err := io.WriteString(s.out, strings.Join(fields, "\t")+"\n")
+
+	return err
+}
@@ -0,0 +1,333 @@
+// Package indexes drops the secondary indexes of a table before a large load and recreates them afterwards,
+// which is faster than maintaining them on every insert. The definitions are saved in a record file before
+// dropping them, so an interrupted run can restore them.
+package indexes
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+)
+
+// Version is the version of the record format.
+const Version = 1
+
+// Definition is the definition of an index: the index clause of SHOW CREATE TABLE on MySQL and the
+// CREATE INDEX statement of pg_get_indexdef on Postgres.
+type Definition struct {
+	Name string `json:"name"`
+	SQL  string `json:"sql"`
+}
+
+// Record holds the definitions of the indexes dropped from a table.
+type Record struct {
+	Version int          `json:"version"`
+	DBType  string       `json:"db_type"`
+	Schema  string       `json:"schema"`
+	Table   string       `json:"table"`
+	Indexes []Definition `json:"indexes"`
+}
+
+// mysqlIndexClause matches the secondary index clauses of SHOW CREATE TABLE, capturing the index name.
+var mysqlIndexClause = regexp.MustCompile("^(?:UNIQUE |FULLTEXT |SPATIAL )?KEY `((?:[^`]|``)+)`")
+
+// Capture returns the definitions of the secondary indexes of the table. MySQL indexes needed by a foreign
+// key and Postgres indexes backing a constraint are kept, as they cannot be dropped on their own. Unique
+// indexes are kept unless dropUnique is set, as duplicated rows would only be detected when recreating them.
+func Capture(ctx context.Context, db *sql.DB, dbType, schema string, table *domain.TableStructure, dropUnique bool,
+) (*Record, error) {
+	var candidates []string
+	for _, index := range table.Indexes {
+		switch {
+		case index.IsPrimary || usedByForeignKey(dbType, index, table.ForeignKeys):
+		case index.IsUnique && !dropUnique:
+			log.Info().Msgf("Unique index %s is kept", index.Name)
+		default:
+			candidates = append(candidates, index.Name)
+		}
+	}
+
+	var definitions map[string]Definition
+	var err error
+	switch dbType {
+	case "mysql":
+		definitions, err = captureMySQL(ctx, db, schema, table.Name)
+	case "postgres":
+		definitions, err = capturePostgres(ctx, db, table.Name)
+	default:
+		return nil, fmt.Errorf("unsupported database type: %s", dbType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the index definitions: %w", err)
+	}
+
+	record := &Record{Version: Version, DBType: dbType, Schema: schema, Table: table.Name, Indexes: []Definition{}}
+	for _, name := range candidates {
+		if definition, ok := definitions[name]; ok {
+			record.Indexes = append(record.Indexes, definition)
+		} else {
+			log.Warn().Msgf("Index %s is kept, it cannot be dropped on its own", name)
+		}
+	}
+
+	return record, nil
+}
+
+// usedByForeignKey reports whether MySQL needs the index for a foreign key, because its leading columns are
+// the ones of the foreign key.
+func usedByForeignKey(dbType string, index domain.TableIndex, foreignKeys []domain.ForeignKey) bool {
+	if dbType != "mysql" {
+		return false
+	}
+
+	for _, fk := range foreignKeys {
+		if len(index.Columns) >= len(fk.Columns) && slices.Equal(index.Columns[:len(fk.Columns)], fk.Columns) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func captureMySQL(ctx context.Context, db *sql.DB, schema, table string) (map[string]Definition, error) {
+	var name, create string
+	query := fmt.Sprintf("SHOW CREATE TABLE %s", mysqlTable(schema, table))
+	if err := db.QueryRowContext(ctx, query).Scan(&name, &create); err != nil {
+		return nil, err
+	}
+
+	definitions := make(map[string]Definition)
+	for _, line := range strings.Split(create, "\n") {
+		clause := strings.TrimSuffix(strings.TrimSpace(line), ",")
+		if match := mysqlIndexClause.FindStringSubmatch(clause); match != nil {
+			indexName := strings.ReplaceAll(match[1], "``", "`")
+			definitions[indexName] = Definition{Name: indexName, SQL: clause}
+		}
+	}
+
+	return definitions, nil
+}
+
+func capturePostgres(ctx context.Context, db *sql.DB, table string) (map[string]Definition, error) {
+	// Indexes backing a unique or exclusion constraint can only be dropped with the constraint
+	query := `
+		SELECT i.relname, pg_get_indexdef(ix.indexrelid)
+		FROM pg_index ix
+		JOIN pg_class i ON i.oid = ix.indexrelid
+		WHERE ix.indrelid = to_regclass($1)
+			AND NOT ix.indisprimary
+			AND NOT EXISTS (SELECT 1 FROM pg_constraint c WHERE c.conindid = ix.indexrelid)`
+
+	rows, err := db.QueryContext(ctx, query, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	definitions := make(map[string]Definition)
+	for rows.Next() {
+		var definition Definition
+		if err = rows.Scan(&definition.Name, &definition.SQL); err != nil {
+			return nil, err
+		}
+		definitions[definition.Name] = definition
+	}
+
+	return definitions, rows.Err()
+}
+
+// Drop drops the indexes of the record that exist.
+func (r *Record) Drop(ctx context.Context, db *sql.DB) error {
+	existing, err := r.existing(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	var statements []string
+	switch r.DBType {
+	case "mysql":
+		var clauses []string
+		for _, index := range r.Indexes {
+			if existing[index.Name] {
+				clauses = append(clauses, "DROP INDEX "+quoteMySQL(index.Name))
+			}
+		}
+		if len(clauses) > 0 {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s %s",
+				mysqlTable(r.Schema, r.Table), strings.Join(clauses, ", ")))
+		}
+	case "postgres":
+		for _, index := range r.Indexes {
+			if existing[index.Name] {
+				statements = append(statements, "DROP INDEX "+r.postgresIndex(index.Name))
+			}
+		}
+	}
+
+	for _, statement := range statements {
+		log.Debug().Msg(statement)
+		if _, err = db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("cannot drop the indexes: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Recreate creates the indexes of the record that do not exist. MySQL builds them with a single ALTER TABLE,
+// reading the table once, while Postgres builds up to parallel indexes at a time.
+func (r *Record) Recreate(ctx context.Context, db *sql.DB, parallel int) error {
+	existing, err := r.existing(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	var statements []string
+	switch r.DBType {
+	case "mysql":
+		var clauses []string
+		for _, index := range r.Indexes {
+			if !existing[index.Name] {
+				clauses = append(clauses, "ADD "+index.SQL)
+			}
+		}
+		if len(clauses) > 0 {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %s %s",
+				mysqlTable(r.Schema, r.Table), strings.Join(clauses, ", ")))
+		}
+	case "postgres":
+		for _, index := range r.Indexes {
+			if !existing[index.Name] {
+				statements = append(statements, index.SQL)
+			}
+		}
+	}
+
+	return execParallel(ctx, db, statements, max(parallel, 1))
+}
+
+// execParallel runs the statements, up to parallel at a time, returning all the errors.
+func execParallel(ctx context.Context, db *sql.DB, statements []string, parallel int) error {
+	pending := make(chan int)
+	errs := make([]error, len(statements))
+	wg := &sync.WaitGroup{}
+	for range min(parallel, len(statements)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range pending {
+				log.Info().Msg(statements[i])
+				if _, err := db.ExecContext(ctx, statements[i]); err != nil {
+					errs[i] = fmt.Errorf("%s: %w", statements[i], err)
+				}
+			}
+		}()
+	}
+	for i := range statements {
+		pending <- i
+	}
+	close(pending)
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// existing returns the names of the indexes of the table.
+func (r *Record) existing(ctx context.Context, db *sql.DB) (map[string]bool, error) {
+	var rows *sql.Rows
+	var err error
+	switch r.DBType {
+	case "mysql":
+		rows, err = db.QueryContext(ctx, `SELECT DISTINCT INDEX_NAME FROM INFORMATION_SCHEMA.STATISTICS
+			WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?`, r.Schema, r.Table)
+	case "postgres":
+		rows, err = db.QueryContext(ctx, `SELECT i.relname FROM pg_index ix JOIN pg_class i ON i.oid = ix.indexrelid
+			WHERE ix.indrelid = to_regclass($1)`, r.Table)
+	default:
+		return nil, fmt.Errorf("unsupported database type: %s", r.DBType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot list the indexes: %w", err)
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		existing[name] = true
+	}
+
+	return existing, rows.Err()
+}
+
+// postgresIndex returns the quoted name of the index, in the schema of the table.
+func (r *Record) postgresIndex(name string) string {
+	quote := func(identifier string) string {
+		return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+	}
+
+	// The indexes are created in the schema of their table
+	schema, _, found := strings.Cut(r.Table, ".")
+	if !found {
+		return quote(name)
+	}
+
+	return quote(schema) + "." + quote(name)
+}
+
+func mysqlTable(schema, table string) string {
+	if schema == "" {
+		return quoteMySQL(table)
+	}
+
+	return quoteMySQL(schema) + "." + quoteMySQL(table)
+}
+
+func quoteMySQL(identifier string) string {
+	return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+}
+
+// Save writes the record to the file.
+func (r *Record) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("cannot write the index record: %w", err)
+	}
+
+	return nil
+}
+
+// ReadRecord reads a record written by Save.
+func ReadRecord(path string) (*Record, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the index record: %w", err)
+	}
+
+	var r Record
+	if err = json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("invalid index record %s: %w", path, err)
+	}
+	if r.Version != Version {
+		return nil, fmt.Errorf("unsupported index record version %d, expected %d", r.Version, Version)
+	}
+
+	return &r, nil
+}
@@ -0,0 +1,44 @@
+package indexes_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/services/indexes"
+)
+
+func TestRecordSaveRead(t *testing.T) {
+	record := &indexes.Record{
+		Version: indexes.Version,
+		DBType:  "mysql",
+		Schema:  "shop",
+		Table:   "orders",
+		Indexes: []indexes.Definition{
+			{Name: "idx_customer", SQL: "KEY `idx_customer` (`customer_id`,`created_at`)"},
+			{Name: "uq_reference", SQL: "UNIQUE KEY `uq_reference` (`reference`)"},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "orders.indexes.json")
+	require.NoError(t, record.Save(path))
+
+	read, err := indexes.ReadRecord(path)
+	require.NoError(t, err)
+	assert.Equal(t, record, read)
+}
+
+func TestReadRecordErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := indexes.ReadRecord(filepath.Join(dir, "missing.json"))
+	require.Error(t, err)
+
+	path := filepath.Join(dir, "future.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 99, "table": "orders"}`), 0o600))
+	_, err = indexes.ReadRecord(path)
+	require.ErrorContains(t, err, "version")
+}
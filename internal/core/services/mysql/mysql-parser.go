@@ -38,6 +38,11 @@ func Parse(dbConn any, schema, tableName string) (*domain.TableStructure, error)
 		return nil, fmt.Errorf("error parsing foreign keys: %w", err)
 	}
 
+	// Get partitions
+	if err := parsePartitions(db, schema, tableName, tableStruct); err != nil {
+		return nil, fmt.Errorf("error parsing partitions: %w", err)
+	}
+
 	return tableStruct, nil
 }
 
@@ -48,7 +53,9 @@ func parseColumns(db *sql.DB, schema, tableName string, tableStruct *domain.Tabl
 			COLUMN_NAME, 
 			DATA_TYPE, 
 			IS_NULLABLE, 
-			COLUMN_DEFAULT
+			COLUMN_DEFAULT,
+			NUMERIC_PRECISION,
+			NUMERIC_SCALE
 		FROM 
 			INFORMATION_SCHEMA.COLUMNS 
 		WHERE 
@@ -67,8 +74,11 @@ func parseColumns(db *sql.DB, schema, tableName string, tableStruct *domain.Tabl
 	for rows.Next() {
 		var column domain.TableColumn
 		var isNullable, columnDefault sql.NullString
+		var precision, scale sql.NullInt64
 
-		if err := rows.Scan(&column.Name, &column.DataType, &isNullable, &columnDefault); err != nil {
+		if err := rows.Scan(
+			&column.Name, &column.DataType, &isNullable, &columnDefault, &precision, &scale,
+		); err != nil {
 			return err
 		}
 
@@ -76,6 +86,8 @@ func parseColumns(db *sql.DB, schema, tableName string, tableStruct *domain.Tabl
 		if columnDefault.Valid {
 			column.Default = columnDefault.String
 		}
+		column.Precision = int(precision.Int64)
+		column.Scale = int(scale.Int64)
 
 		tableStruct.Columns = append(tableStruct.Columns, column)
 	}
@@ -89,7 +101,8 @@ func parseIndexes(db *sql.DB, schema, tableName string, tableStruct *domain.Tabl
 		SELECT 
 			INDEX_NAME,
 			COLUMN_NAME,
-			NON_UNIQUE
+			NON_UNIQUE,
+			SUB_PART
 		FROM 
 			INFORMATION_SCHEMA.STATISTICS
 		WHERE 
@@ -111,8 +124,9 @@ func parseIndexes(db *sql.DB, schema, tableName string, tableStruct *domain.Tabl
 	for rows.Next() {
 		var indexName, columnName string
 		var nonUnique int
+		var subPart sql.NullInt64 // Key prefix length
 
-		if err := rows.Scan(&indexName, &columnName, &nonUnique); err != nil {
+		if err := rows.Scan(&indexName, &columnName, &nonUnique, &subPart); err != nil {
 			return err
 		}
 
@@ -127,6 +141,12 @@ func parseIndexes(db *sql.DB, schema, tableName string, tableStruct *domain.Tabl
 		}
 
 		index.Columns = append(index.Columns, columnName)
+		if subPart.Valid && index.Prefixes == nil {
+			index.Prefixes = make([]int, len(index.Columns)-1, len(index.Columns))
+		}
+		if index.Prefixes != nil {
+			index.Prefixes = append(index.Prefixes, int(subPart.Int64))
+		}
 	}
 
 	if err := rows.Err(); err != nil {
@@ -199,3 +219,86 @@ func parseForeignKeys(db *sql.DB, schema, tableName string, tableStruct *domain.
 
 	return nil
 }
+
+// parsePartitions fetches and parses the partitions of a table, if partitioned. Subpartitions are ignored.
+func parsePartitions(db *sql.DB, schema, tableName string, tableStruct *domain.TableStructure) error {
+	query := `
+		SELECT
+			PARTITION_NAME,
+			PARTITION_METHOD,
+			PARTITION_EXPRESSION,
+			PARTITION_DESCRIPTION
+		FROM
+			INFORMATION_SCHEMA.PARTITIONS
+		WHERE
+			TABLE_SCHEMA = ?
+			AND TABLE_NAME = ?
+			AND PARTITION_NAME IS NOT NULL
+			AND (SUBPARTITION_ORDINAL_POSITION IS NULL OR SUBPARTITION_ORDINAL_POSITION = 1)
+		ORDER BY
+			PARTITION_ORDINAL_POSITION
+	`
+
+	rows, err := db.Query(query, schema, tableName)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var partitioning *domain.Partitioning
+	previous := "" // Upper bound of the previous range partition
+
+	for rows.Next() {
+		var name, method string
+		var expression, description sql.NullString
+
+		if err := rows.Scan(&name, &method, &expression, &description); err != nil {
+			return err
+		}
+
+		if partitioning == nil {
+			partitioning = &domain.Partitioning{
+				Method:     partitionMethod(method),
+				Expression: strings.ReplaceAll(expression.String, "`", ""),
+			}
+		}
+
+		partition := domain.Partition{Name: name}
+		switch partitioning.Method {
+		case domain.PartitionRange:
+			// Range partitions hold the values less than their description, from the previous one on
+			// The bounds of multiple column keys are kept as they are
+			partition.From = previous
+			bound := domain.ParseLiteralList(description.String)
+			switch {
+			case len(bound) != 1:
+				partition.To = description.String
+			case bound[0] != nil && !strings.EqualFold(*bound[0], "MAXVALUE"):
+				partition.To = *bound[0]
+			}
+			previous = partition.To
+		case domain.PartitionList:
+			partition.Values = domain.ParseLiteralList(description.String)
+		}
+
+		partitioning.Partitions = append(partitioning.Partitions, partition)
+	}
+
+	tableStruct.Partitioning = partitioning
+
+	return rows.Err()
+}
+
+// partitionMethod returns the partitioning method of a MySQL one: the COLUMNS variants are ranges and lists,
+// and the KEY and LINEAR variants are hashes.
+func partitionMethod(method string) string {
+	method = strings.ToUpper(method)
+	switch {
+	case strings.HasPrefix(method, "RANGE"):
+		return domain.PartitionRange
+	case strings.HasPrefix(method, "LIST"):
+		return domain.PartitionList
+	default:
+		return domain.PartitionHash
+	}
+}
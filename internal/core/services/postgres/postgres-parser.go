@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/cfsalguero/random_data_loader/internal/core/domain"
@@ -37,6 +38,11 @@ func Parse(dbConn any, schema, tableName string) (*domain.TableStructure, error)
 		return nil, fmt.Errorf("error parsing foreign keys: %w", err)
 	}
 
+	// Get partitions
+	if err := parsePartitions(db, schema, tableName, tableStruct); err != nil {
+		return nil, fmt.Errorf("error parsing partitions: %w", err)
+	}
+
 	return tableStruct, nil
 }
 
@@ -47,7 +53,9 @@ func parseColumns(db *sql.DB, schema, tableName string, tableStruct *domain.Tabl
 			column_name, 
 			data_type, 
 			is_nullable, 
-			column_default
+			column_default,
+			numeric_precision,
+			numeric_scale
 		FROM 
 			information_schema.columns 
 		WHERE 
@@ -66,8 +74,11 @@ func parseColumns(db *sql.DB, schema, tableName string, tableStruct *domain.Tabl
 	for rows.Next() {
 		var column domain.TableColumn
 		var isNullable, columnDefault sql.NullString
+		var precision, scale sql.NullInt64
 
-		if err := rows.Scan(&column.Name, &column.DataType, &isNullable, &columnDefault); err != nil {
+		if err := rows.Scan(
+			&column.Name, &column.DataType, &isNullable, &columnDefault, &precision, &scale,
+		); err != nil {
 			return err
 		}
 
@@ -75,6 +86,8 @@ func parseColumns(db *sql.DB, schema, tableName string, tableStruct *domain.Tabl
 		if columnDefault.Valid {
 			column.Default = columnDefault.String
 		}
+		column.Precision = int(precision.Int64)
+		column.Scale = int(scale.Int64)
 
 		tableStruct.Columns = append(tableStruct.Columns, column)
 	}
@@ -214,3 +227,93 @@ func parseForeignKeys(db *sql.DB, schema, tableName string, tableStruct *domain.
 
 	return nil
 }
+
+// partitionBound matches the bounds of the range and list partitions returned by pg_get_expr.
+var partitionBound = regexp.MustCompile(`(?is)^FOR VALUES (?:FROM \((.*)\) TO \((.*)\)|IN \((.*)\))$`)
+
+// parsePartitions fetches and parses the partitions of a table, if partitioned. Subpartitions are ignored.
+func parsePartitions(db *sql.DB, schema, tableName string, tableStruct *domain.TableStructure) error {
+	var key string
+	err := db.QueryRow(`
+		SELECT
+			pg_get_partkeydef(pt.partrelid)
+		FROM
+			pg_partitioned_table pt
+		WHERE
+			pt.partrelid = to_regclass(quote_ident($1) || '.' || quote_ident($2))
+	`, schema, tableName).Scan(&key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// The key is like RANGE (created_at)
+	method, expression, _ := strings.Cut(key, " ")
+	partitioning := &domain.Partitioning{
+		Method:     strings.ToLower(method),
+		Expression: strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(expression), "("), ")"),
+	}
+
+	query := `
+		SELECT
+			c.relname,
+			pg_get_expr(c.relpartbound, c.oid)
+		FROM
+			pg_inherits i
+			JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE
+			i.inhparent = to_regclass(quote_ident($1) || '.' || quote_ident($2))
+		ORDER BY
+			c.relname
+	`
+
+	rows, err := db.Query(query, schema, tableName)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, bound string
+
+		if err := rows.Scan(&name, &bound); err != nil {
+			return err
+		}
+
+		partition := domain.Partition{Name: name, IsDefault: strings.EqualFold(bound, "DEFAULT")}
+		if match := partitionBound.FindStringSubmatch(bound); match != nil {
+			if match[3] != "" {
+				partition.Values = domain.ParseLiteralList(match[3])
+			} else {
+				partition.From = rangeBound(match[1])
+				partition.To = rangeBound(match[2])
+			}
+		}
+
+		partitioning.Partitions = append(partitioning.Partitions, partition)
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	tableStruct.Partitioning = partitioning
+
+	return nil
+}
+
+// rangeBound returns the value of a range partition bound, empty if unbounded. The bounds of multiple
+// column keys are returned as they are.
+func rangeBound(bound string) string {
+	values := domain.ParseLiteralList(bound)
+	if len(values) != 1 {
+		return bound
+	}
+	if values[0] == nil || strings.EqualFold(*values[0], "MINVALUE") || strings.EqualFold(*values[0], "MAXVALUE") {
+		return ""
+	}
+
+	return *values[0]
+}
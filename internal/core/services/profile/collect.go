@@ -0,0 +1,210 @@
+package profile
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/dataloader"
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+)
+
+const (
+	// topValues is the number of most frequent values recorded per column.
+	topValues = 20
+	// histogramBuckets is the number of buckets of the equi-depth histograms.
+	histogramBuckets = 20
+)
+
+// timeLayouts are the layouts used to parse times returned as text by the drivers.
+var timeLayouts = []string{ //nolint:gochecknoglobals // Constant list.
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999",
+	time.DateTime,
+	time.DateOnly,
+}
+
+// Collect samples about sampleRows random rows of the table and builds their profile.
+func Collect(ctx context.Context, db *sql.DB, dbType string, table *domain.TableStructure, sampleRows int) (*Profile, error) {
+	names := make([]string, len(table.Columns))
+	for i, column := range table.Columns {
+		names[i] = column.Name
+	}
+
+	query, err := dataloader.SampleQuery(ctx, db, dbType, table.Name, strings.Join(names, ", "), "", sampleRows)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("cannot sample %s: %w", table.Name, err)
+	}
+	defer rows.Close()
+
+	var sample [][]any
+	for rows.Next() {
+		row := make([]any, len(names))
+		pointers := make([]any, len(row))
+		for i := range row {
+			pointers[i] = &row[i]
+		}
+		if err = rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("cannot sample %s: %w", table.Name, err)
+		}
+		sample = append(sample, row)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("cannot sample %s: %w", table.Name, err)
+	}
+
+	return Build(table, sample), nil
+}
+
+// Build builds the profile of the sample rows, whose values follow the order of the table columns.
+func Build(table *domain.TableStructure, sample [][]any) *Profile {
+	p := &Profile{
+		Version:     profileVersion,
+		Table:       table.Name,
+		SampledRows: len(sample),
+	}
+
+	for i, column := range table.Columns {
+		values := make([]any, len(sample))
+		for j, row := range sample {
+			values[j] = row[i]
+		}
+		p.Columns = append(p.Columns, buildColumn(column, values))
+	}
+
+	return p
+}
+
+func buildColumn(column domain.TableColumn, values []any) ColumnProfile {
+	profile := ColumnProfile{
+		Name:     column.Name,
+		DataType: column.DataType,
+		Kind:     kindOf(column.DataType),
+	}
+
+	counts := make(map[string]int)
+	var numbers []float64
+	var lengths []int
+	nulls := 0
+	for _, value := range values {
+		if value == nil {
+			nulls++
+			continue
+		}
+
+		text := textOf(value)
+		counts[text]++
+
+		switch profile.Kind {
+		case KindNumber:
+			if number, err := strconv.ParseFloat(text, 64); err == nil {
+				numbers = append(numbers, number)
+			}
+		case KindTime:
+			if t, ok := timeOf(value); ok {
+				numbers = append(numbers, float64(t.UnixNano())/float64(time.Second))
+			}
+		case KindString:
+			lengths = append(lengths, len([]rune(text)))
+		}
+	}
+
+	if len(values) > 0 {
+		profile.NullFraction = float64(nulls) / float64(len(values))
+	}
+	profile.Values = len(values) - nulls
+	profile.DistinctCount = len(counts)
+	profile.TopValues = topCounts(counts, topValues)
+	if profile.Kind == KindString {
+		// Strings may be personal data: only their lengths are kept, which is all the generators use
+		for i, top := range profile.TopValues {
+			profile.TopValues[i] = ValueCount{Length: len([]rune(top.Value)), Count: top.Count}
+		}
+	}
+
+	switch {
+	case len(numbers) > 0:
+		slices.Sort(numbers)
+		profile.Histogram = quantiles(numbers)
+		profile.Min, profile.Max = boundText(profile.Kind, numbers[0]), boundText(profile.Kind, numbers[len(numbers)-1])
+	case len(lengths) > 0:
+		slices.Sort(lengths)
+		profile.Lengths = quantiles(lengths)
+		profile.Min, profile.Max = strconv.Itoa(lengths[0]), strconv.Itoa(lengths[len(lengths)-1])
+	}
+
+	return profile
+}
+
+// topCounts returns the n most frequent values, the most frequent first.
+func topCounts(counts map[string]int, n int) []ValueCount {
+	top := make([]ValueCount, 0, len(counts))
+	for value, count := range counts {
+		top = append(top, ValueCount{Value: value, Count: count})
+	}
+	slices.SortFunc(top, func(a, b ValueCount) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Value, b.Value)
+	})
+
+	return top[:min(n, len(top))]
+}
+
+// quantiles returns the bounds of the equi-depth buckets of the sorted values.
+func quantiles[T int | float64](sorted []T) []T {
+	bounds := make([]T, histogramBuckets+1)
+	for i := range bounds {
+		bounds[i] = sorted[int(math.Round(float64(i)*float64(len(sorted)-1)/histogramBuckets))]
+	}
+
+	return bounds
+}
+
+func boundText(kind string, value float64) string {
+	if kind == KindTime {
+		return time.Unix(0, int64(value*float64(time.Second))).UTC().Format(time.RFC3339)
+	}
+
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func textOf(value any) string {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func timeOf(value any) (time.Time, bool) {
+	if t, ok := value.(time.Time); ok {
+		return t, true
+	}
+
+	text := textOf(value)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
@@ -0,0 +1,286 @@
+package profile
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/dataloader"
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+)
+
+// Apply replaces the generators of the loader columns by generators mirroring the profile statistics.
+// Columns without a generator, like auto-increment keys, or without a profile keep their generators,
+// as do string columns of special types (JSON, UUIDs, addresses, etc.), whose values must be valid.
+func Apply(loader *dataloader.TableDataLoader, p *Profile) error {
+	if p.Table != loader.TableStruct.Name {
+		return fmt.Errorf("the profile is for table %s, not %s", p.Table, loader.TableStruct.Name)
+	}
+
+	for _, column := range loader.TableStruct.Columns {
+		current, ok := loader.Generators[column.Name]
+		profile := p.Column(column.Name)
+		if !ok || profile == nil {
+			continue
+		}
+		if _, isString := current.(*dataloader.StringGenerator); profile.Kind == KindString && !isString {
+			continue
+		}
+
+		generator := NewGenerator(column, profile)
+		if generator == nil {
+			// Only NULL values were sampled
+			generator = current
+		}
+		if profile.NullFraction > 0 {
+			generator = dataloader.NewNullableGenerator(generator, profile.NullFraction)
+		}
+		loader.SetGenerator(column.Name, generator)
+	}
+
+	return nil
+}
+
+// NewGenerator returns a generator mirroring the column profile, or nil if the profile has no values.
+// Columns whose sampled values are all among the top values, like statuses or flags, get the same values
+// with the same frequencies, replacing strings by random ones of the same length. Numbers and times follow
+// the histogram, and strings the length distribution.
+func NewGenerator(column domain.TableColumn, profile *ColumnProfile) dataloader.DataGenerator {
+	if len(profile.TopValues) == 0 {
+		return nil
+	}
+
+	categorical := profile.DistinctCount <= len(profile.TopValues)
+	switch {
+	case categorical && profile.Kind != KindString:
+		values := make([]any, len(profile.TopValues))
+		for i, top := range profile.TopValues {
+			values[i] = typedValue(column, profile.Kind, top.Value)
+		}
+		return NewWeightedGenerator(values, counts(profile.TopValues))
+
+	case categorical:
+		return NewWeightedGenerator(anyOf(syntheticValues(profile.TopValues)), counts(profile.TopValues))
+
+	case profile.Kind == KindNumber && len(profile.Histogram) > 0:
+		decimals := -1
+		if isInteger(column.DataType) {
+			decimals = 0
+		} else if column.Precision > 0 {
+			decimals = column.Scale
+		}
+		return &HistogramGenerator{Bounds: profile.Histogram, Decimals: decimals}
+
+	case profile.Kind == KindTime && len(profile.Histogram) > 0:
+		return &TimeGenerator{Bounds: profile.Histogram, AsText: timeAsText(column.DataType)}
+
+	case profile.Kind == KindString && len(profile.Lengths) > 0:
+		return newTextGenerator(profile)
+
+	default:
+		// Values that could not be parsed, sampled as they are
+		return NewWeightedGenerator(anyOf(syntheticValues(profile.TopValues)), counts(profile.TopValues))
+	}
+}
+
+// WeightedGenerator generates values from a fixed set with the given relative frequencies.
+type WeightedGenerator struct {
+	Values     []any
+	Weights    []int
+	cumulative []int
+}
+
+// NewWeightedGenerator creates a new generator picking the values with the given weights.
+func NewWeightedGenerator(values []any, weights []int) *WeightedGenerator {
+	cumulative := make([]int, len(weights))
+	total := 0
+	for i, weight := range weights {
+		total += weight
+		cumulative[i] = total
+	}
+
+	return &WeightedGenerator{
+		Values:     values,
+		Weights:    weights,
+		cumulative: cumulative,
+	}
+}
+
+// GenerateValue generates one of the values.
+func (g *WeightedGenerator) GenerateValue(r *rand.Rand) interface{} {
+	n := r.IntN(g.cumulative[len(g.cumulative)-1])
+	i, _ := slices.BinarySearch(g.cumulative, n+1)
+
+	return g.Values[i]
+}
+
+// HistogramGenerator generates numbers following an equi-depth histogram: every bucket is equally likely
+// and values are uniform within a bucket.
+type HistogramGenerator struct {
+	Bounds   []float64
+	Decimals int // Decimals to round to, -1 to keep all, 0 for integers
+}
+
+// GenerateValue generates a number.
+func (g *HistogramGenerator) GenerateValue(r *rand.Rand) interface{} {
+	value := sampleBuckets(r, g.Bounds)
+	switch {
+	case g.Decimals == 0:
+		return int64(math.Round(value))
+	case g.Decimals > 0:
+		scale := math.Pow10(g.Decimals)
+		return math.Round(value*scale) / scale
+	default:
+		return value
+	}
+}
+
+// TimeGenerator generates times following an equi-depth histogram of Unix seconds.
+type TimeGenerator struct {
+	Bounds []float64
+	AsText bool // Generate times as text without time zone, like TimestampGenerator does
+}
+
+// GenerateValue generates a time.
+func (g *TimeGenerator) GenerateValue(r *rand.Rand) interface{} {
+	seconds := sampleBuckets(r, g.Bounds)
+	t := time.Unix(0, int64(seconds*float64(time.Second)))
+	if g.AsText {
+		return t.UTC().Format(time.DateTime)
+	}
+
+	return t
+}
+
+// TextGenerator generates random strings with lengths following an equi-depth histogram. A fraction of the
+// values are picked from a set of frequent values instead.
+type TextGenerator struct {
+	Lengths  []int
+	Frequent *WeightedGenerator
+	Ratio    float64 // Fraction of frequent values
+}
+
+func newTextGenerator(profile *ColumnProfile) *TextGenerator {
+	g := &TextGenerator{Lengths: profile.Lengths}
+
+	// Values seen only once are not frequent, they are left to the length distribution
+	var frequent []ValueCount
+	total := 0
+	for _, top := range profile.TopValues {
+		if top.Count > 1 {
+			frequent = append(frequent, top)
+			total += top.Count
+		}
+	}
+	if len(frequent) > 0 && profile.Values > 0 {
+		g.Frequent = NewWeightedGenerator(anyOf(syntheticValues(frequent)), counts(frequent))
+		g.Ratio = float64(total) / float64(profile.Values)
+	}
+
+	return g
+}
+
+// GenerateValue generates a string.
+func (g *TextGenerator) GenerateValue(r *rand.Rand) interface{} {
+	if g.Frequent != nil && r.Float64() < g.Ratio {
+		return g.Frequent.GenerateValue(r)
+	}
+
+	bucket := r.IntN(max(len(g.Lengths)-1, 1))
+	low, high := g.Lengths[bucket], g.Lengths[min(bucket+1, len(g.Lengths)-1)]
+
+	return randomString(r, low+r.IntN(high-low+1))
+}
+
+// sampleBuckets returns a uniform value within a random bucket of the bounds.
+func sampleBuckets(r *rand.Rand, bounds []float64) float64 {
+	if len(bounds) == 1 {
+		return bounds[0]
+	}
+
+	bucket := r.IntN(len(bounds) - 1)
+	low, high := bounds[bucket], bounds[bucket+1]
+
+	return low + r.Float64()*(high-low)
+}
+
+// syntheticValues returns random strings with the lengths of the values, so real values are not copied.
+// The strings only depend on the position and length of the values, so they are stable across runs.
+func syntheticValues(values []ValueCount) []string {
+	synthetic := make([]string, len(values))
+	for i, value := range values {
+		length := value.Length
+		if value.Value != "" {
+			length = len([]rune(value.Value))
+		}
+		r := rand.New(rand.NewPCG(uint64(i), uint64(length)))
+		synthetic[i] = randomString(r, length)
+	}
+
+	return synthetic
+}
+
+func counts(values []ValueCount) []int {
+	weights := make([]int, len(values))
+	for i, value := range values {
+		weights[i] = value.Count
+	}
+
+	return weights
+}
+
+func anyOf(values []string) []any {
+	result := make([]any, len(values))
+	for i, value := range values {
+		result[i] = value
+	}
+
+	return result
+}
+
+// typedValue returns a sampled value as the default generators of the column type would generate it.
+func typedValue(column domain.TableColumn, kind, value string) any {
+	switch {
+	case kind == KindTime:
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return value
+		}
+		if timeAsText(column.DataType) {
+			return t.UTC().Format(time.DateTime)
+		}
+		return t
+	case isInteger(column.DataType):
+		if number, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return number
+		}
+	}
+
+	return value
+}
+
+func isInteger(dataType string) bool {
+	dataType = strings.ToLower(dataType)
+	return strings.Contains(dataType, "int") && !strings.Contains(dataType, "interval")
+}
+
+// timeAsText reports whether the default generator of the data type generates times as text.
+func timeAsText(dataType string) bool {
+	dataType = strings.ToLower(dataType)
+	return !strings.HasPrefix(dataType, "date ") && dataType != "date" &&
+		!strings.Contains(dataType, "with time zone") && !strings.Contains(dataType, "timestamptz")
+}
+
+func randomString(r *rand.Rand, length int) string {
+	const chars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	result := make([]byte, length)
+	for i := range result {
+		result[i] = chars[r.IntN(len(chars))]
+	}
+
+	return string(result)
+}
@@ -0,0 +1,117 @@
+// Package profile learns per-column statistics from existing data and builds generators mirroring them.
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// profileVersion is the version of the profile file format.
+const profileVersion = 1
+
+// Column kinds, deciding which statistics are collected.
+const (
+	KindNumber = "number"
+	KindTime   = "time"
+	KindString = "string"
+	KindEnum   = "enum" // Enums, sets and booleans, whose values come from the schema
+)
+
+// Profile holds the statistics of a sample of the rows of a table.
+type Profile struct {
+	Version     int             `json:"version"`
+	Table       string          `json:"table"`
+	SampledRows int             `json:"sampled_rows"`
+	Columns     []ColumnProfile `json:"columns"`
+}
+
+// ColumnProfile holds the statistics of a column.
+type ColumnProfile struct {
+	Name          string       `json:"name"`
+	DataType      string       `json:"data_type"`
+	Kind          string       `json:"kind"`
+	NullFraction  float64      `json:"null_fraction"`
+	Values        int          `json:"values"`         // Non NULL values in the sample
+	DistinctCount int          `json:"distinct_count"` // Distinct values in the sample
+	Min           string       `json:"min,omitempty"`  // Lowest number or time, or shortest string length
+	Max           string       `json:"max,omitempty"`  // Highest number or time, or longest string length
+	TopValues     []ValueCount `json:"top_values,omitempty"`
+	// Histogram has the bounds of equi-depth buckets of numbers, or of times as Unix seconds
+	Histogram []float64 `json:"histogram,omitempty"`
+	// Lengths has the bounds of equi-depth buckets of string lengths
+	Lengths []int `json:"lengths,omitempty"`
+}
+
+// ValueCount is a value and the number of times it appears in the sample. String values are not recorded,
+// only their length.
+type ValueCount struct {
+	Value  string `json:"value,omitempty"`
+	Length int    `json:"length,omitempty"`
+	Count  int    `json:"count"`
+}
+
+// Column returns the profile of a column, or nil if the column is not profiled.
+func (p *Profile) Column(name string) *ColumnProfile {
+	for i := range p.Columns {
+		if p.Columns[i].Name == name {
+			return &p.Columns[i]
+		}
+	}
+
+	return nil
+}
+
+// Save writes the profile to a JSON file.
+func (p *Profile) Save(path string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot encode profile: %w", err)
+	}
+
+	if err = os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("cannot write profile: %w", err)
+	}
+
+	return nil
+}
+
+// Load reads a profile from a JSON file.
+func Load(path string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read profile: %w", err)
+	}
+
+	p := &Profile{}
+	if err = json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("cannot decode profile %s: %w", path, err)
+	}
+	if p.Version != profileVersion {
+		return nil, fmt.Errorf("unsupported profile version %d in %s", p.Version, path)
+	}
+
+	return p, nil
+}
+
+// kindOf returns the kind of a column data type.
+func kindOf(dataType string) string {
+	tokens := strings.FieldsFunc(strings.ToLower(dataType), func(r rune) bool { return r == ' ' || r == '(' })
+	if len(tokens) == 0 {
+		return KindString
+	}
+	token := tokens[0]
+
+	switch {
+	case token == "enum" || token == "set" || strings.HasPrefix(token, "bool"):
+		return KindEnum
+	case strings.Contains(token, "int") || token == "decimal" || token == "numeric" || token == "float" ||
+		token == "double" || token == "real" || token == "money":
+		return KindNumber
+	case token == "date" || token == "datetime" || strings.HasPrefix(token, "timestamp"):
+		return KindTime
+	default:
+		return KindString
+	}
+}
@@ -0,0 +1,148 @@
+package profile_test
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/dataloader"
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+	"github.com/cfsalguero/random_data_loader/internal/core/services/profile"
+)
+
+func testTable() *domain.TableStructure {
+	return &domain.TableStructure{
+		Name: "orders",
+		Columns: []domain.TableColumn{
+			{Name: "id", DataType: "int"},
+			{Name: "status", DataType: "varchar(10)"},
+			{Name: "amount", DataType: "decimal(10,2)", Precision: 10, Scale: 2},
+			{Name: "created_at", DataType: "datetime"},
+			{Name: "note", DataType: "text", Nullable: true},
+		},
+		Indexes: []domain.TableIndex{{Name: "PRIMARY", Columns: []string{"id"}, IsPrimary: true}},
+	}
+}
+
+// testSample returns 1000 rows with three statuses, amounts between 0 and 999, a year of times and
+// notes of 10 to 18 characters, half of them NULL.
+func testSample() [][]any {
+	statuses := []string{"new", "new", "new", "paid", "shipped"}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	rows := make([][]any, 1000)
+	for i := range rows {
+		var note any
+		if i%2 == 0 {
+			note = []byte(fmt.Sprintf("note %015d", i)[:10+i%10])
+		}
+		rows[i] = []any{
+			int64(i + 1),
+			[]byte(statuses[i%len(statuses)]),
+			[]byte(fmt.Sprintf("%d.50", i)),
+			start.Add(time.Duration(i) * 8 * time.Hour),
+			note,
+		}
+	}
+
+	return rows
+}
+
+func TestBuild(t *testing.T) {
+	p := profile.Build(testTable(), testSample())
+	assert.Equal(t, "orders", p.Table)
+	assert.Equal(t, 1000, p.SampledRows)
+	require.Len(t, p.Columns, 5)
+
+	status := p.Column("status")
+	require.NotNil(t, status)
+	assert.Equal(t, profile.KindString, status.Kind)
+	assert.Equal(t, 3, status.DistinctCount)
+	assert.Equal(t, []profile.ValueCount{{Length: 3, Count: 600}, {Length: 4, Count: 200},
+		{Length: 7, Count: 200}}, status.TopValues, "string values are not recorded")
+
+	amount := p.Column("amount")
+	require.NotNil(t, amount)
+	assert.Equal(t, profile.KindNumber, amount.Kind)
+	assert.Equal(t, "0.5", amount.Min)
+	assert.Equal(t, "999.5", amount.Max)
+	require.Len(t, amount.Histogram, 21)
+	assert.InDelta(t, 500, amount.Histogram[10], 1)
+
+	createdAt := p.Column("created_at")
+	require.NotNil(t, createdAt)
+	assert.Equal(t, profile.KindTime, createdAt.Kind)
+	assert.Equal(t, "2024-01-01T00:00:00Z", createdAt.Min)
+
+	note := p.Column("note")
+	require.NotNil(t, note)
+	assert.InDelta(t, 0.5, note.NullFraction, 0.001)
+	assert.Equal(t, 500, note.Values)
+	assert.Equal(t, "10", note.Min)
+	assert.Equal(t, "18", note.Max)
+	assert.Nil(t, p.Column("missing"))
+}
+
+func TestSaveLoad(t *testing.T) {
+	p := profile.Build(testTable(), testSample())
+	path := filepath.Join(t.TempDir(), "orders.json")
+	require.NoError(t, p.Save(path))
+
+	loaded, err := profile.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, p, loaded)
+}
+
+func TestApply(t *testing.T) {
+	table := testTable()
+	p := profile.Build(table, testSample())
+	loader := dataloader.NewTableDataLoader(nil, "mysql", table, 100, 1)
+	require.NoError(t, loader.SetDefaultGenerators())
+	require.NoError(t, profile.Apply(loader, p))
+
+	_, generated := loader.Generators["id"]
+	assert.False(t, generated, "auto-increment keys are left to the database")
+
+	r := rand.New(rand.NewPCG(1, 2))
+	statuses := make(map[any]int)
+	nulls := 0
+	for range 10000 {
+		statuses[loader.Generators["status"].GenerateValue(r)]++
+
+		amount, ok := loader.Generators["amount"].GenerateValue(r).(float64)
+		require.True(t, ok)
+		assert.GreaterOrEqual(t, amount, 0.5)
+		assert.LessOrEqual(t, amount, 999.5)
+
+		createdAt, ok := loader.Generators["created_at"].GenerateValue(r).(string)
+		require.True(t, ok)
+		assert.GreaterOrEqual(t, createdAt, "2024-01-01 00:00:00")
+
+		switch note := loader.Generators["note"].GenerateValue(r).(type) {
+		case nil:
+			nulls++
+		case string:
+			assert.GreaterOrEqual(t, len(note), 10)
+			assert.LessOrEqual(t, len(note), 18)
+		default:
+			t.Fatalf("unexpected note %v", note)
+		}
+	}
+
+	require.Len(t, statuses, 3)
+	assert.NotContains(t, statuses, "new", "real values are not copied")
+	for value, count := range statuses {
+		if len(fmt.Sprint(value)) == len("new") {
+			assert.InDelta(t, 6000, count, 300, "the frequency of new is kept")
+		}
+	}
+	assert.InDelta(t, 5000, nulls, 300)
+
+	other := &domain.TableStructure{Name: "customers"}
+	assert.Error(t, profile.Apply(dataloader.NewTableDataLoader(nil, "mysql", other, 100, 1), p))
+}
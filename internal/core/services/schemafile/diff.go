@@ -0,0 +1,141 @@
+package schemafile
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+)
+
+// Diff returns the differences between two structures of a table, one per line, in the order of the
+// columns, indexes, foreign keys and partitions. Lines start with + for what is only in to, - for what is only in from,
+// and ~ for what changed.
+func Diff(from, to *domain.TableStructure) []string {
+	var diff []string
+
+	if from.Name != to.Name {
+		diff = append(diff, fmt.Sprintf("~ table %s: renamed to %s", from.Name, to.Name))
+	}
+
+	diff = append(diff, diffNamed("column", from.Columns, to.Columns,
+		func(c domain.TableColumn) string { return c.Name }, describeColumn)...)
+	diff = append(diff, diffNamed("index", from.Indexes, to.Indexes,
+		func(i domain.TableIndex) string { return i.Name }, describeIndex)...)
+	diff = append(diff, diffNamed("foreign key", from.ForeignKeys, to.ForeignKeys,
+		func(fk domain.ForeignKey) string { return fk.Name }, describeForeignKey)...)
+	diff = append(diff, diffPartitioning(from.Partitioning, to.Partitioning)...)
+
+	return diff
+}
+
+// diffPartitioning compares the partitioning method and key, and then the partitions.
+func diffPartitioning(from, to *domain.Partitioning) []string {
+	var diff []string
+	var fromPartitions, toPartitions []domain.Partition
+
+	switch {
+	case from == nil && to == nil:
+		return nil
+	case from == nil:
+		diff = append(diff, "+ partitioning "+describePartitioning(to))
+	case to == nil:
+		diff = append(diff, "- partitioning "+describePartitioning(from))
+	case describePartitioning(from) != describePartitioning(to):
+		diff = append(diff, fmt.Sprintf("~ partitioning: %s -> %s", describePartitioning(from),
+			describePartitioning(to)))
+	}
+	if from != nil {
+		fromPartitions = from.Partitions
+	}
+	if to != nil {
+		toPartitions = to.Partitions
+	}
+
+	return append(diff, diffNamed("partition", fromPartitions, toPartitions,
+		func(p domain.Partition) string { return p.Name }, describePartition)...)
+}
+
+// diffNamed compares two lists of elements identified by name, using the description to detect changes.
+func diffNamed[T any](kind string, from, to []T, name func(T) string, describe func(T) string) []string {
+	var diff []string
+
+	for _, old := range from {
+		i := slices.IndexFunc(to, func(e T) bool { return name(e) == name(old) })
+		switch {
+		case i < 0:
+			diff = append(diff, strings.TrimSpace(fmt.Sprintf("- %s %s %s", kind, name(old), describe(old))))
+		case describe(old) != describe(to[i]):
+			diff = append(diff, fmt.Sprintf("~ %s %s: %s -> %s", kind, name(old), describe(old), describe(to[i])))
+		}
+	}
+
+	for _, e := range to {
+		if !slices.ContainsFunc(from, func(old T) bool { return name(old) == name(e) }) {
+			diff = append(diff, strings.TrimSpace(fmt.Sprintf("+ %s %s %s", kind, name(e), describe(e))))
+		}
+	}
+
+	return diff
+}
+
+func describeColumn(column domain.TableColumn) string {
+	description := column.DataType
+	base := strings.ToLower(strings.Split(column.DataType, " ")[0])
+	if (base == "decimal" || base == "numeric") && column.Precision > 0 {
+		// The catalogs return the precision and scale apart from the type
+		description += fmt.Sprintf("(%d,%d)", column.Precision, column.Scale)
+	}
+	if !column.Nullable {
+		description += " NOT NULL"
+	}
+	if column.Default != "" {
+		description += " DEFAULT " + column.Default
+	}
+
+	return description
+}
+
+func describeIndex(index domain.TableIndex) string {
+	description := "(" + strings.Join(index.Columns, ", ") + ")"
+	switch {
+	case index.IsPrimary:
+		description = "PRIMARY KEY " + description
+	case index.IsUnique:
+		description = "UNIQUE " + description
+	}
+
+	return description
+}
+
+func describeForeignKey(fk domain.ForeignKey) string {
+	return fmt.Sprintf("(%s) REFERENCES %s (%s)",
+		strings.Join(fk.Columns, ", "), fk.ReferencedTable, strings.Join(fk.ReferencedColumns, ", "))
+}
+
+func describePartitioning(partitioning *domain.Partitioning) string {
+	return strings.ToUpper(partitioning.Method) + " (" + partitioning.Expression + ")"
+}
+
+func describePartition(partition domain.Partition) string {
+	switch {
+	case partition.IsDefault:
+		return "DEFAULT"
+	case partition.Values != nil:
+		values := make([]string, len(partition.Values))
+		for i, value := range partition.Values {
+			values[i] = "NULL"
+			if value != nil {
+				values[i] = "'" + *value + "'"
+			}
+		}
+		return "IN (" + strings.Join(values, ", ") + ")"
+	case partition.From != "" || partition.To != "":
+		from, to := cmp.Or(partition.From, "MINVALUE"), cmp.Or(partition.To, "MAXVALUE")
+		return "FROM (" + from + ") TO (" + to + ")"
+	default:
+		// Hash partitions
+		return ""
+	}
+}
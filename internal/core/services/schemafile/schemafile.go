@@ -0,0 +1,129 @@
+// Package schemafile reads and writes table structures as JSON or YAML snapshots, so data can be generated
+// without access to the source database and schemas can be compared. Structures are also read from DDL files.
+package schemafile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+	"github.com/cfsalguero/random_data_loader/internal/core/services/ddl"
+)
+
+// Version is the version of the snapshot format.
+const Version = 1
+
+// Snapshot formats.
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// snapshot is a table structure with the version of the format.
+type snapshot struct {
+	Version               int `json:"version" yaml:"version"`
+	domain.TableStructure `yaml:",inline"`
+}
+
+// Encode encodes the table structure as a JSON or YAML snapshot.
+func Encode(table *domain.TableStructure, format string) ([]byte, error) {
+	s := snapshot{Version: Version, TableStructure: *table}
+	// Empty lists are encoded as such rather than as null, so snapshots of equal tables are equal
+	if s.Indexes == nil {
+		s.Indexes = []domain.TableIndex{}
+	}
+	if s.ForeignKeys == nil {
+		s.ForeignKeys = []domain.ForeignKey{}
+	}
+
+	var data []byte
+	var err error
+	switch format {
+	case FormatJSON:
+		data, err = json.MarshalIndent(s, "", "  ")
+		data = append(data, '\n')
+	case FormatYAML:
+		data, err = yaml.Marshal(s)
+	default:
+		return nil, fmt.Errorf("unsupported snapshot format: %s", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode the structure of %s: %w", table.Name, err)
+	}
+
+	return data, nil
+}
+
+// createTable matches the CREATE TABLE statements of DDL files.
+var createTable = regexp.MustCompile(`(?i)\bCREATE\s+(?:TEMPORARY\s+|UNLOGGED\s+)?TABLE\b`)
+
+// Decode decodes a JSON or YAML snapshot, or a DDL file with the CREATE TABLE statement of a single table.
+func Decode(data []byte) (*domain.TableStructure, error) {
+	if trimmed := bytes.TrimSpace(data); (len(trimmed) == 0 || trimmed[0] != '{') && createTable.Match(data) {
+		return decodeDDL(string(data))
+	}
+
+	var s snapshot
+	var err error
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&s)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&s)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode the table structure: %w", err)
+	}
+
+	if s.Version != Version {
+		return nil, fmt.Errorf("unsupported table structure version %d", s.Version)
+	}
+	if s.Name == "" || len(s.Columns) == 0 {
+		return nil, errors.New("the table structure has no table name or columns")
+	}
+
+	return &s.TableStructure, nil
+}
+
+// decodeDDL parses the table of a DDL file.
+func decodeDDL(data string) (*domain.TableStructure, error) {
+	tables, err := ddl.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse the DDL: %w", err)
+	}
+	if len(tables) > 1 {
+		names := make([]string, len(tables))
+		for i, table := range tables {
+			names[i] = table.Name
+		}
+		return nil, fmt.Errorf("the DDL creates %d tables (%s), it must create a single one",
+			len(tables), strings.Join(names, ", "))
+	}
+
+	return tables[0], nil
+}
+
+// Read reads a snapshot or DDL file.
+func Read(path string) (*domain.TableStructure, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the table structure: %w", err)
+	}
+
+	table, err := Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return table, nil
+}
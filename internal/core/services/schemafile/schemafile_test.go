@@ -0,0 +1,129 @@
+package schemafile_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+	"github.com/cfsalguero/random_data_loader/internal/core/services/schemafile"
+)
+
+func testTable() *domain.TableStructure {
+	return &domain.TableStructure{
+		Name: "orders",
+		Columns: []domain.TableColumn{
+			{Name: "id", DataType: "int"},
+			{Name: "customer_id", DataType: "int"},
+			{Name: "amount", DataType: "decimal(10,2)", Nullable: true, Precision: 10, Scale: 2},
+			{Name: "status", DataType: "varchar(10)", Default: "'new'"},
+		},
+		Indexes: []domain.TableIndex{
+			{Name: "PRIMARY", Columns: []string{"id"}, IsPrimary: true, IsUnique: true},
+			{Name: "idx_status", Columns: []string{"status"}},
+		},
+		ForeignKeys: []domain.ForeignKey{
+			{Name: "fk_customer", Columns: []string{"customer_id"}, ReferencedTable: "customers", ReferencedColumns: []string{"id"}},
+		},
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	for _, format := range []string{schemafile.FormatJSON, schemafile.FormatYAML} {
+		t.Run(format, func(t *testing.T) {
+			data, err := schemafile.Encode(testTable(), format)
+			require.NoError(t, err)
+			assert.Contains(t, string(data), "version")
+			assert.Contains(t, string(data), "referenced_table")
+
+			path := filepath.Join(t.TempDir(), "orders."+format)
+			require.NoError(t, os.WriteFile(path, data, 0o600))
+			table, err := schemafile.Read(path)
+			require.NoError(t, err)
+			assert.Equal(t, testTable(), table)
+		})
+	}
+
+	_, err := schemafile.Encode(testTable(), "xml")
+	require.Error(t, err)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	_, err := schemafile.Decode([]byte(`{"version": 2, "name": "orders", "columns": [{"name": "id"}]}`))
+	require.ErrorContains(t, err, "version 2")
+
+	_, err = schemafile.Decode([]byte(`{"version": 1, "name": "orders"}`))
+	require.ErrorContains(t, err, "no table name or columns")
+
+	_, err = schemafile.Decode([]byte(`{"version": 1, "name": "orders", "colums": []}`))
+	require.ErrorContains(t, err, "unknown field")
+
+	_, err = schemafile.Decode([]byte("version: 1\nname: orders\ncolums: []\n"))
+	require.ErrorContains(t, err, "field colums not found")
+}
+
+func TestDecodeDDL(t *testing.T) {
+	table, err := schemafile.Decode([]byte(`
+		CREATE TABLE orders (
+			id int NOT NULL,
+			customer_id int NOT NULL,
+			amount decimal(10,2),
+			status varchar(10) NOT NULL DEFAULT 'new',
+			PRIMARY KEY (id),
+			KEY idx_status (status),
+			CONSTRAINT fk_customer FOREIGN KEY (customer_id) REFERENCES customers (id)
+		);`))
+	require.NoError(t, err)
+	assert.Equal(t, testTable(), table)
+
+	_, err = schemafile.Decode([]byte("CREATE TABLE a (id int); CREATE TABLE b (id int);"))
+	require.ErrorContains(t, err, "2 tables (a, b)")
+}
+
+func TestDiff(t *testing.T) {
+	assert.Empty(t, schemafile.Diff(testTable(), testTable()))
+
+	changed := testTable()
+	changed.Columns[2].DataType = "decimal(12,2)"
+	changed.Columns = append(changed.Columns[:3], domain.TableColumn{Name: "note", DataType: "text", Nullable: true})
+	changed.Indexes = changed.Indexes[:1]
+	changed.ForeignKeys[0].ReferencedTable = "clients"
+
+	assert.Equal(t, []string{
+		"~ column amount: decimal(10,2) -> decimal(12,2)",
+		"- column status varchar(10) NOT NULL DEFAULT 'new'",
+		"+ column note text",
+		"- index idx_status (status)",
+		"~ foreign key fk_customer: (customer_id) REFERENCES customers (id) -> (customer_id) REFERENCES clients (id)",
+	}, schemafile.Diff(testTable(), changed))
+
+	// Precision and scale returned apart from the type
+	from, to := testTable(), testTable()
+	from.Columns[2].DataType, to.Columns[2].DataType = "numeric", "numeric"
+	to.Columns[2].Precision, to.Columns[2].Scale = 12, 4
+	assert.Equal(t, []string{"~ column amount: numeric(10,2) -> numeric(12,4)"}, schemafile.Diff(from, to))
+}
+
+func TestDiffPartitioning(t *testing.T) {
+	partitioned := func(to string) *domain.TableStructure {
+		table := testTable()
+		table.Partitioning = &domain.Partitioning{
+			Method:     domain.PartitionRange,
+			Expression: "id",
+			Partitions: []domain.Partition{{Name: "p0", To: "100"}, {Name: "p1", From: "100", To: to}},
+		}
+		return table
+	}
+
+	assert.Empty(t, schemafile.Diff(partitioned("200"), partitioned("200")))
+	assert.Equal(t, []string{"~ partition p1: FROM (100) TO (200) -> FROM (100) TO (300)"},
+		schemafile.Diff(partitioned("200"), partitioned("300")))
+	assert.Equal(t, []string{
+		"+ partitioning RANGE (id)",
+		"+ partition p0 FROM (MINVALUE) TO (100)",
+		"+ partition p1 FROM (100) TO (200)",
+	}, schemafile.Diff(testTable(), partitioned("200")))
+}
@@ -0,0 +1,169 @@
+// Package sqldump implements a dataloader sink that writes generated rows as INSERT statements
+package sqldump
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+)
+
+// mysqlEscaper escapes string literals the same way as mysql_real_escape_string.
+var mysqlEscaper = strings.NewReplacer( //nolint:gochecknoglobals // Immutable replacer.
+	`\`, `\\`,
+	"'", `\'`,
+	`"`, `\"`,
+	"\x00", `\0`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\x1a", `\Z`,
+)
+
+// Sink writes rows as multi-row INSERT statements that can be replayed with mysql or psql.
+// Every batch is rendered as a single statement, optionally wrapped in its own transaction.
+type Sink struct {
+	out          io.WriteCloser
+	dbType       string
+	transactions bool
+	columns      []domain.TableColumn
+	insert       string
+	buf          strings.Builder
+}
+
+// New creates a new SQL dump sink for the given database type ("mysql" or "postgres").
+func New(out io.WriteCloser, dbType string, transactions bool) (*Sink, error) {
+	if dbType != "mysql" && dbType != "postgres" {
+		return nil, fmt.Errorf("unsupported database type: %s", dbType)
+	}
+
+	return &Sink{
+		out:          out,
+		dbType:       dbType,
+		transactions: transactions,
+	}, nil
+}
+
+// Begin writes the session settings the literals rely on and prepares the INSERT header.
+func (s *Sink) Begin(table *domain.TableStructure, columns []domain.TableColumn) error {
+	s.columns = columns
+
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = QuoteIdentifier(s.dbType, column.Name)
+	}
+	s.insert = fmt.Sprintf("INSERT INTO %s (%s) VALUES\n", QuoteIdentifier(s.dbType, table.Name), strings.Join(names, ", "))
+
+	preamble := "SET standard_conforming_strings = on;\n"
+	if s.dbType == "mysql" {
+		preamble = "SET time_zone = '+00:00';\n"
+	}
+	_, err := io.WriteString(s.out, preamble)
+
+	return err
+}
+
+// WriteBatch writes all the rows as a single INSERT statement.
+func (s *Sink) WriteBatch(rows [][]any) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	s.buf.Reset()
+	if s.transactions {
+		if s.dbType == "mysql" {
+			s.buf.WriteString("START TRANSACTION;\n")
+		} else {
+			s.buf.WriteString("BEGIN;\n")
+		}
+	}
+
+	s.buf.WriteString(s.insert)
+	for i, row := range rows {
+		if i > 0 {
+			s.buf.WriteString(",\n")
+		}
+		s.buf.WriteByte('(')
+		for j, value := range row {
+			if j > 0 {
+				s.buf.WriteString(", ")
+			}
+			s.buf.WriteString(Literal(s.dbType, value, s.columns[j].DataType))
+		}
+		s.buf.WriteByte(')')
+	}
+	s.buf.WriteString(";\n")
+
+	if s.transactions {
+		s.buf.WriteString("COMMIT;\n")
+	}
+
+	_, err := io.WriteString(s.out, s.buf.String())
+
+	return err
+}
+
+// Close closes the output.
+func (s *Sink) Close() error {
+	return s.out.Close()
+}
+
+// QuoteIdentifier quotes a table or column name for the given database type.
+func QuoteIdentifier(dbType, name string) string {
+	if dbType == "mysql" {
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// Literal renders a generated value as a SQL literal for the given database type.
+// Postgres literals assume standard_conforming_strings is on and MySQL timestamps assume a UTC session.
+func Literal(dbType string, value any, dataType string) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []byte:
+		if dbType == "mysql" {
+			return "X'" + hex.EncodeToString(v) + "'"
+		}
+		return `'\x` + hex.EncodeToString(v) + "'"
+	case time.Time:
+		return quoteString(dbType, timeLiteral(dbType, v, dataType))
+	case string:
+		return quoteString(dbType, v)
+	default:
+		return quoteString(dbType, fmt.Sprint(v))
+	}
+}
+
+func timeLiteral(dbType string, t time.Time, dataType string) string {
+	if strings.EqualFold(dataType, "date") {
+		return t.Format(time.DateOnly)
+	}
+	if dbType == "mysql" {
+		return t.UTC().Format("2006-01-02 15:04:05.999999")
+	}
+
+	return t.Format("2006-01-02 15:04:05.999999-07:00")
+}
+
+func quoteString(dbType, value string) string {
+	if dbType == "mysql" {
+		return "'" + mysqlEscaper.Replace(value) + "'"
+	}
+
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
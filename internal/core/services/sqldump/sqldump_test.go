@@ -0,0 +1,77 @@
+package sqldump_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cfsalguero/random_data_loader/internal/core/domain"
+	"github.com/cfsalguero/random_data_loader/internal/core/services/sqldump"
+)
+
+func TestLiteral(t *testing.T) {
+	ts := time.Date(2020, 2, 3, 4, 5, 6, 500000000, time.FixedZone("X", 3600))
+
+	tests := []struct {
+		name     string
+		dbType   string
+		value    any
+		dataType string
+		want     string
+	}{
+		{"null", "mysql", nil, "int", "NULL"},
+		{"bool", "postgres", true, "boolean", "TRUE"},
+		{"int", "mysql", int64(-42), "int", "-42"},
+		{"float", "postgres", 1.5, "real", "1.5"},
+		{"mysql string", "mysql", "it's a \\ \"test\"\n", "varchar", `'it\'s a \\ \"test\"\n'`},
+		{"postgres string", "postgres", "it's a \\ test", "text", `'it''s a \ test'`},
+		{"mysql bytes", "mysql", []byte{0xca, 0xfe}, "blob", "X'cafe'"},
+		{"postgres bytes", "postgres", []byte{0xca, 0xfe}, "bytea", `'\xcafe'`},
+		{"mysql timestamp", "mysql", ts, "timestamp", "'2020-02-03 03:05:06.5'"},
+		{"postgres timestamp", "postgres", ts, "timestamp with time zone", "'2020-02-03 04:05:06.5+01:00'"},
+		{"date", "postgres", ts, "date", "'2020-02-03'"},
+		{"json", "postgres", `{"k": "v'"}`, "jsonb", `'{"k": "v''"}'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, sqldump.Literal(tt.dbType, tt.value, tt.dataType))
+		})
+	}
+}
+
+func TestSinkWritesBatches(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.sql")
+	out, err := os.Create(path)
+	require.NoError(t, err)
+
+	sink, err := sqldump.New(out, "postgres", true)
+	require.NoError(t, err)
+
+	columns := []domain.TableColumn{{Name: "id", DataType: "integer"}, {Name: "name", DataType: "text"}}
+	require.NoError(t, sink.Begin(&domain.TableStructure{Name: "users"}, columns))
+	require.NoError(t, sink.WriteBatch([][]any{{int64(1), "a"}, {int64(2), nil}}))
+	require.NoError(t, sink.WriteBatch([][]any{{int64(3), "c"}}))
+	require.NoError(t, sink.Close())
+
+	got, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	want := "SET standard_conforming_strings = on;\n" +
+		"BEGIN;\n" +
+		"INSERT INTO \"users\" (\"id\", \"name\") VALUES\n(1, 'a'),\n(2, NULL);\n" +
+		"COMMIT;\n" +
+		"BEGIN;\n" +
+		"INSERT INTO \"users\" (\"id\", \"name\") VALUES\n(3, 'c');\n" +
+		"COMMIT;\n"
+	assert.Equal(t, want, string(got))
+}
+
+func TestUnsupportedDBType(t *testing.T) {
+	_, err := sqldump.New(nil, "oracle", false)
+	require.Error(t, err)
+}